@@ -1,14 +1,64 @@
 package errs
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
 	botV5 "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
-// BroadcastBot struct handles sending messages to multiple Telegram chats
-type broadcastBot struct {
-	bot     *botV5.BotAPI
-	chatIDs []int64
-	trimSpace bool
+// BotSinkName is the name of the broadcast bot in the sink statistics and throttles.
+// The loggers are named after their LogType.
+const BotSinkName = "TELEGRAM"
+
+// maxMessageLength is the maximum length of a Telegram message, in UTF-16 code units.
+const maxMessageLength = 4096
+
+// botSendTimeout bounds sending an entry delivered in the background, see Entry.sendContext.
+const botSendTimeout = time.Minute
+
+// LongMessageMode controls how the bot delivers log messages that exceed the Telegram message limit.
+type LongMessageMode string
+
+const (
+	LongMessageSplit    LongMessageMode = "SPLIT"    // Split the JSON across several code-block messages.
+	LongMessageDocument LongMessageMode = "DOCUMENT" // Send a short summary and attach the full JSON as a file.
+)
+
+// BroadcastBot sends log entries to Telegram chats.
+// A process can run several bots, each with its own token, chats and service name;
+// attach them to loggers with Logger.SetBot or to routes with Route.Bot.
+type BroadcastBot struct {
+	bot          *botV5.BotAPI
+	self         atomic.Pointer[botV5.User] // The bot user, once known, see Verify.
+	endpoint     string
+	service      string
+	chatIDs      []int64
+	trimSpace    bool
+	longMessages LongMessageMode
+	parseMode    ParseMode
+	routing      atomic.Pointer[botRoutes] // Routes of the bot, see SetRoutes.
+	digest       *digest
+	commandChats []int64
+	commands     bool
+	pollWait     time.Duration // Long-polling timeout of getUpdates, see longPollTimeout.
+	buttons      bool
+	repeats      *repeats
+	tmpl         *notificationTemplate
+	timeZone     *time.Location  // Time zone of the templates.
+	timeFormat   string          // Time format of the templates.
+	ctx          context.Context // Cancelled when the bot is closed.
+	stop         context.CancelFunc
+	wg           sync.WaitGroup // Background loops of the bot.
 }
 
 type BroadcastBotParams struct {
@@ -16,48 +66,621 @@ type BroadcastBotParams struct {
 	Token       string
 	ChatIDs     []int64
 	TrimSpace   bool
+
+	// LongMessages selects how messages longer than 4096 characters are delivered.
+	// The zero value behaves like LongMessageSplit.
+	LongMessages LongMessageMode
+
+	// ParseMode selects the message formatting. The zero value is ParseModeMarkdown.
+	ParseMode ParseMode
+
+	// Routes send matching entries to specific chats and forum topics.
+	// Entries no route matches go to DefaultRoute or, if it is empty, to all ChatIDs.
+	Routes       []Route
+	DefaultRoute []ChatTarget
+
+	// APIEndpoint is the Bot API server to talk to, either a base URL such as
+	// "http://localhost:8081" or a format like botV5.APIEndpoint. Empty uses api.telegram.org.
+	APIEndpoint string
+	// HTTPClient is used for all Bot API requests, for proxies, timeouts or TLS settings.
+	// With Commands or Buttons, the long polls wait for half its Timeout at most, up to 30s.
+	// Nil uses a default client.
+	HTTPClient *http.Client
+	// Lazy skips the getMe request at construction, so no connection is made until the first message.
+	Lazy bool
+
+	// Digest batches errors per time window instead of sending one message per error.
+	Digest DigestParams
+
+	// Commands enables a long-polling loop that answers /status, /stats, /mute and /unmute.
+	Commands bool
+	// CommandChatIDs are the chats allowed to send commands. Empty allows the chats in ChatIDs.
+	CommandChatIDs []int64
+	// Buttons attaches Ack, Mute 1h and Mute 24h buttons to every alert. Pressing one edits the
+	// alert to show who pressed it and when; the mute buttons also mute the fingerprint.
+	Buttons bool
+
+	// EditRepeats makes repeats of an error edit the alert already sent for its fingerprint,
+	// showing an occurrence counter, instead of sending a new message. A new message is only
+	// sent once EditRepeats has passed since the first one. Zero sends every error.
+	EditRepeats time.Duration
+
+	// Template is a text/template that replaces the default message format. Routes can override
+	// it with Route.Template. It is executed with the Entry being sent, for example:
+	//
+	//	{{ escape .Service }} {{ .Level }} at {{ formatTime .Time }}
+	//	{{ escape .Message }}: {{ escape .RootCause }}
+	//	{{ code (json .Request) }}
+	//
+	// Besides the text/template built-ins, the following functions are available:
+	//   - escape: escapes text for the parse mode of the bot.
+	//   - code: renders text as a code block in the parse mode of the bot.
+	//   - json: formats a value as indented JSON.
+	//   - truncate: shortens text to at most n characters, for example {{ truncate 200 .ErrorPath }}.
+	//   - formatTime: formats a time in TimeZone and TimeFormat.
+	//
+	// The message is rendered in the parse mode of the bot, and as plain text for when Telegram
+	// rejects the formatting. Messages that fail or do not fit fall back to the default format.
+	Template string
+	// TimeZone and TimeFormat configure the formatTime template function.
+	// Nil TimeZone keeps the zone of the time; empty TimeFormat uses time.RFC3339Nano.
+	TimeZone   *time.Location
+	TimeFormat string
+
+	// Verify checks at construction that the bot can post to every configured chat, see BroadcastBot.Verify.
+	Verify bool
+	// TestMessage sends a test message with the service name and host to every chat at construction.
+	TestMessage bool
 }
 
-// NewBroadcastBot creates a new instance of BroadcastBot
+// NewBroadcastBot creates a new BroadcastBot and attaches it to the default logger,
+// so that Log sends every error to Telegram. A bot attached before is closed.
 func NewBroadcastBot(params BroadcastBotParams) error {
-	if params.Token == "" || len(params.ChatIDs) == 0 {
-		return New("Failed to create Telegram bot. Invalid token or chat ID.")
+	b, err := NewBot(params)
+	if err != nil {
+		return err
 	}
 
-	sTitle = params.ServiceName
+	setServiceName(params.ServiceName)
+	if old := Default().SetBot(b); old != nil {
+		old.Close()
+	}
+
+	return nil
+}
+
+// NewBot creates a new BroadcastBot without attaching it to any logger.
+// The bot runs its background loops, such as digests and commands, until it is closed.
+//
+// Parameters:
+//   - params: The token, chats and options of the bot.
+//
+// Returns:
+//   - The bot, or an error if the parameters are invalid or the token cannot be checked.
+func NewBot(params BroadcastBotParams) (*BroadcastBot, error) {
+	if params.Token == "" || len(params.ChatIDs) == 0 && len(params.Routes) == 0 && len(params.DefaultRoute) == 0 {
+		return nil, New("Failed to create Telegram bot. Invalid token or chat ID.")
+	}
 
-	b, err := botV5.NewBotAPI(params.Token)
+	endpoint := apiEndpoint(params.APIEndpoint)
+	b, err := newBotAPI(params, endpoint)
 	if err != nil {
-		return Wrap(err, "failed to create telegram bot")
+		return nil, Wrap(err, "failed to create telegram bot")
 	}
 
-	bot = &broadcastBot{
-		bot:     b,
-		chatIDs: params.ChatIDs,
-		trimSpace: params.TrimSpace,
+	ctx, stop := context.WithCancel(context.Background())
+	bb := &BroadcastBot{
+		bot:          b,
+		endpoint:     endpoint,
+		service:      params.ServiceName,
+		chatIDs:      params.ChatIDs,
+		trimSpace:    params.TrimSpace,
+		longMessages: params.LongMessages,
+		parseMode:    params.ParseMode,
+		commandChats: params.CommandChatIDs,
+		commands:     params.Commands,
+		pollWait:     longPollTimeout(params.HTTPClient),
+		buttons:      params.Buttons,
+		timeZone:     params.TimeZone,
+		timeFormat:   params.TimeFormat,
+		ctx:          ctx,
+		stop:         stop,
+	}
+	if b.Self.ID != 0 {
+		self := b.Self
+		bb.self.Store(&self)
+	}
+
+	if bb.tmpl, err = bb.parseTemplate(params.Template); err != nil {
+		bb.stop()
+		return nil, err
+	}
+	if err := bb.SetRoutes(params.Routes, params.DefaultRoute); err != nil {
+		bb.stop()
+		return nil, err
+	}
+
+	if params.Verify || params.TestMessage {
+		if err := bb.selfTest(params); err != nil {
+			bb.stop()
+			return nil, err
+		}
+	}
+
+	if params.EditRepeats > 0 {
+		bb.repeats = newRepeats(params.EditRepeats)
+	}
+
+	if params.Digest.Window > 0 {
+		bb.digest = newDigest(params.Digest)
+		bb.wg.Add(1)
+		go func() {
+			defer bb.wg.Done()
+			bb.runDigest()
+		}()
 	}
 
+	if params.Commands || params.Buttons {
+		if len(bb.commandChats) == 0 {
+			bb.commandChats = params.ChatIDs
+		}
+		bb.wg.Add(1)
+		go func() {
+			defer bb.wg.Done()
+			bb.pollUpdates()
+		}()
+	}
+
+	return bb, nil
+}
+
+// parseTemplate compiles a notification template of the bot. It returns nil for an empty template.
+func (bb *BroadcastBot) parseTemplate(text string) (*notificationTemplate, error) {
+	if text == "" {
+		return nil, nil
+	}
+	return newNotificationTemplate(text, bb.parseMode, bb.timeZone, bb.timeFormat)
+}
+
+// selfTest runs the checks requested in params when the bot is created.
+func (bb *BroadcastBot) selfTest(params BroadcastBotParams) error {
+	ctx, cancel := context.WithTimeout(context.Background(), verifyTimeout)
+	defer cancel()
+
+	if params.Verify {
+		if _, err := bb.Verify(ctx); err != nil {
+			return Wrap(err, "telegram bot self-test failed")
+		}
+	}
+
+	if params.TestMessage {
+		if err := bb.SendTestMessage(ctx); err != nil {
+			return Wrap(err, "telegram bot self-test failed")
+		}
+	}
 	return nil
 }
 
-// SendMessage sends a message to all configured chat IDs
-func (bb *broadcastBot) sendMessage(msg string) error {
+// Close stops the background loops of the bot and waits for them to finish,
+// sending any pending digest. A closed bot still sends the entries passed to it,
+// one message per entry, so entries in flight while a bot is replaced are not lost.
+// Close is safe to call more than once.
+func (bb *BroadcastBot) Close() {
+	bb.stop()
+	bb.wg.Wait()
+}
+
+// apiEndpoint returns the Bot API endpoint format for the APIEndpoint parameter.
+func apiEndpoint(endpoint string) string {
+	if endpoint == "" {
+		return botV5.APIEndpoint
+	}
+	if !strings.Contains(endpoint, "%s") {
+		return strings.TrimSuffix(endpoint, "/") + "/bot%s/%s"
+	}
+	return endpoint
+}
+
+// newBotAPI creates the Bot API client for the endpoint and the HTTP client in params.
+// Unless the bot is lazy, it checks the token with a getMe request.
+func newBotAPI(params BroadcastBotParams, endpoint string) (*botV5.BotAPI, error) {
+	client := params.HTTPClient
+	if client == nil {
+		client = &http.Client{}
+	}
+
+	if !params.Lazy {
+		return botV5.NewBotAPIWithClient(params.Token, endpoint, client)
+	}
+
+	b := &botV5.BotAPI{Token: params.Token, Client: client, Buffer: 100}
+	b.SetAPIEndpoint(endpoint)
+	return b, nil
+}
+
+// logMessage is a bot message made of a plain text header, a JSON body shown as a code block
+// and a plain text footer. It is kept unformatted so it can be rendered in any parse mode.
+type logMessage struct {
+	header   string
+	body     string
+	footer   string
+	keyboard *botV5.InlineKeyboardMarkup // Inline buttons attached to the message, if any.
+
+	// A message rendered by a notification template has no parts; it holds
+	// the text in the parse mode of the bot and as plain text instead.
+	templated bool
+	formatted string
+	plain     string
+}
+
+// render formats the message for the parse mode, escaping every part accordingly.
+// A templated message is already rendered, in the parse mode of the bot or as plain text.
+func (m logMessage) render(mode ParseMode) string {
+	if m.templated {
+		if mode.telegram() == "" {
+			return m.plain
+		}
+		return m.formatted
+	}
+	return mode.escape(m.header) + mode.codeBlock(m.body) + mode.escape(m.footer)
+}
+
+// prepend returns the message with a line of plain text added in front of it.
+func (m logMessage) prepend(line string, mode ParseMode) logMessage {
+	if m.templated {
+		m.formatted = mode.escape(line) + m.formatted
+		m.plain = line + m.plain
+		return m
+	}
+	m.header = line + m.header
+	return m
+}
+
+// append returns the message with plain text added after it.
+func (m logMessage) append(text string, mode ParseMode) logMessage {
+	if m.templated {
+		m.formatted += mode.escape(text)
+		m.plain += text
+		return m
+	}
+	m.footer += text
+	return m
+}
+
+// notify sends an entry to the chats it is routed to,
+// or hands it over to another bot if the matching route says so.
+func (bb *BroadcastBot) notify(e Entry) error {
+	if r := bb.routes().match(e); r != nil && r.Bot != nil && r.Bot != bb {
+		return r.Bot.deliver(e)
+	}
+	return bb.deliver(e)
+}
+
+// deliver sends an entry to the chats it is routed to, ignoring routes to other bots.
+// In digest mode the entry is collected for the digest instead, unless it is to be sent immediately.
+// Sending is bounded by the context of the entry, see Entry.sendContext.
+func (bb *BroadcastBot) deliver(e Entry) error {
+	if bb.digest != nil && bb.ctx.Err() == nil && !bb.digest.add(e) {
+		return nil
+	}
+
+	header := "Service Name: " + bb.serviceName(e) + "\n"
+	if instance := e.Metadata.instance(); instance != "" {
+		header += "Host: " + instance + "\n"
+	}
+	if e.Metadata.Environment != "" {
+		header += "Environment: " + e.Metadata.Environment + "\n"
+	}
+	header += "T: " + e.Time.Format(time.RFC3339Nano) + "\n"
+	if e.ID != "" {
+		header += "Error ID: " + e.ID + "\n"
+	}
+	header += "Fingerprint: " + e.Fingerprint + "\n"
+
+	routing := bb.routes()
+	msg := logMessage{header: header, body: bb.formatJSON(e.json())}
+	if tmpl := bb.template(routing, e); tmpl != nil {
+		// Templates that fail or do not fit into a message fall back to the default format.
+		templated, err := tmpl.message(e)
+		reportBotError(err)
+		if err == nil && textLength(templated.render(bb.parseMode)) <= bb.messageLimit() {
+			msg = templated
+		}
+	}
+	if bb.buttons {
+		msg.keyboard = alertKeyboard(e.Fingerprint)
+	}
+
+	ctx, cancel := e.sendContext()
+	defer cancel()
+
+	chats := bb.routeWith(routing, e)
+	if bb.repeats != nil {
+		return bb.sendRepeat(ctx, chats, e, msg)
+	}
+	return bb.sendLog(ctx, chats, msg)
+}
+
+// serviceName returns the service an entry is sent as: the service name of the bot,
+// or that of the entry if the bot has none.
+func (bb *BroadcastBot) serviceName(e Entry) string {
+	if bb.service != "" {
+		return bb.service
+	}
+	return e.Service
+}
+
+// template returns the notification template for an entry: the template of the first
+// matching route or, if it has none, the template of the bot. It returns nil without templates.
+func (bb *BroadcastBot) template(routing *botRoutes, e Entry) *notificationTemplate {
+	if r := routing.match(e); r != nil && r.tmpl != nil {
+		return r.tmpl
+	}
+	return bb.tmpl
+}
+
+// formatJSON trims or pretty-prints JSON for a message, depending on the TrimSpace setting.
+func (bb *BroadcastBot) formatJSON(jsonMsg string) string {
+	if bb.trimSpace {
+		return strings.TrimSpace(jsonMsg)
+	}
+
+	var prettyJSON bytes.Buffer
+	if err := json.Indent(&prettyJSON, []byte(jsonMsg), "", "  "); err != nil {
+		return jsonMsg
+	}
+	return prettyJSON.String()
+}
+
+// sendLog sends a log message to the chats.
+// Messages that do not fit into a single Telegram message are split or attached as a document,
+// depending on the configured LongMessageMode. Only the first message carries the buttons.
+func (bb *BroadcastBot) sendLog(ctx context.Context, chats []ChatTarget, msg logMessage) error {
 	var errs error
-	for _, chatID := range bb.chatIDs {
-		if err := bb.sendToChat(chatID, msg); err != nil {
+	for _, chat := range chats {
+		if _, err := bb.sendLogTo(ctx, chat, msg); err != nil {
 			errs = Join(" && ", errs, err)
 		}
 	}
 	return errs
 }
 
-// sendToChat sends a message to a specific chat ID
-func (bb *broadcastBot) sendToChat(chatID int64, msg string) error {
-	m := botV5.NewMessage(chatID, msg)
-	m.ParseMode = "Markdown"
-	_, err := bb.bot.Send(m)
+// sentMessage is a message the bot sent, kept to edit it later.
+type sentMessage struct {
+	id  int
+	msg logMessage
+}
+
+// sendLogTo sends a log message to a single chat and returns the first message sent:
+// the message itself, the first part of a split message or the summary of a document.
+func (bb *BroadcastBot) sendLogTo(ctx context.Context, chat ChatTarget, msg logMessage) (sentMessage, error) {
+	limit := bb.messageLimit()
+	if textLength(msg.render(bb.parseMode)) <= limit {
+		id, err := bb.sendToChat(ctx, chat, msg)
+		return sentMessage{id: id, msg: msg}, err
+	}
+
+	if bb.longMessages == LongMessageDocument {
+		summary := summarizeLog(bb.parseMode, msg.header, msg.body, limit)
+		summary.keyboard = msg.keyboard
+
+		id, err := bb.sendToChat(ctx, chat, summary)
+		if err != nil {
+			return sentMessage{}, err
+		}
+
+		// UploadFiles cannot be cancelled, so at least do not start the upload once ctx is done.
+		if err := ctx.Err(); err != nil {
+			return sentMessage{}, err
+		}
+		doc := botV5.RequestFile{Name: "document", Data: botV5.FileBytes{Name: "log.json", Bytes: []byte(msg.body)}}
+		if _, err := bb.bot.UploadFiles("sendDocument", chat.params(), []botV5.RequestFile{doc}); err != nil {
+			return sentMessage{}, WrapF(err, "failed to send document to chat %d", chat.ChatID)
+		}
+		return sentMessage{id: id, msg: summary}, nil
+	}
+
+	msgs := splitLog(bb.parseMode, msg.header, msg.body, limit)
+	msgs[0].keyboard = msg.keyboard
+
+	first := sentMessage{msg: msgs[0]}
+	for i, m := range msgs {
+		id, err := bb.sendToChat(ctx, chat, m)
+		if err != nil {
+			return sentMessage{}, err
+		}
+		if i == 0 {
+			first.id = id
+		}
+	}
+	return first, nil
+}
+
+// messageLimit returns the length a new message may have. When repeats are counted
+// in the message, room is left for the counter line added by later edits.
+func (bb *BroadcastBot) messageLimit() int {
+	if bb.repeats != nil {
+		return maxMessageLength - repeatCounterLength
+	}
+	return maxMessageLength
+}
+
+// sendToChat sends a message to a specific chat and returns its message ID.
+func (bb *BroadcastBot) sendToChat(ctx context.Context, chat ChatTarget, msg logMessage) (int, error) {
+	result, err := bb.postMessage(ctx, "sendMessage", chat.params(), msg)
 	if err != nil {
-		return WrapF(err, "failed to send message to chat %d", chatID)
+		return 0, WrapF(err, "failed to send message to chat %d", chat.ChatID)
 	}
-	return nil
+
+	var sent struct {
+		MessageID int `json:"message_id"`
+	}
+	if err := json.Unmarshal(result, &sent); err != nil {
+		return 0, WrapF(err, "failed to decode message sent to chat %d", chat.ChatID)
+	}
+	return sent.MessageID, nil
+}
+
+// postMessage calls a Bot API method that sends or edits a message, adding the rendered text,
+// the parse mode and the buttons of msg to params.
+// If Telegram rejects the formatting, the message is sent again as plain text.
+func (bb *BroadcastBot) postMessage(ctx context.Context, method string, params botV5.Params, msg logMessage) (json.RawMessage, error) {
+	params["text"] = msg.render(bb.parseMode)
+	params.AddNonEmpty("parse_mode", bb.parseMode.telegram())
+	if msg.keyboard != nil {
+		if err := params.AddInterface("reply_markup", msg.keyboard); err != nil {
+			return nil, Wrap(err, "failed to encode inline keyboard")
+		}
+	}
+
+	result, err := bb.request(ctx, method, params)
+	if err != nil && isParseError(err) && params["parse_mode"] != "" {
+		recordRetry(BotSinkName)
+		params["text"] = msg.render(ParseModeText)
+		delete(params, "parse_mode")
+		result, err = bb.request(ctx, method, params)
+	}
+	return result, err
+}
+
+// request calls a Bot API method and returns its result.
+// Unlike botV5.BotAPI.MakeRequest, the request is cancelled with ctx.
+// A call the Bot API rejects returns a *botV5.Error.
+func (bb *BroadcastBot) request(ctx context.Context, method string, params botV5.Params) (json.RawMessage, error) {
+	values := url.Values{}
+	for key, value := range params {
+		values.Set(key, value)
+	}
+
+	endpoint := fmt.Sprintf(bb.endpoint, bb.bot.Token, method)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(values.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := bb.bot.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var apiResp botV5.APIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, err
+	}
+
+	if !apiResp.Ok {
+		tgErr := &botV5.Error{Code: apiResp.ErrorCode, Message: apiResp.Description}
+		if apiResp.Parameters != nil {
+			tgErr.ResponseParameters = *apiResp.Parameters
+		}
+		return nil, tgErr
+	}
+
+	return apiResp.Result, nil
+}
+
+// params returns the request parameters addressing the chat and its forum topic.
+func (c ChatTarget) params() botV5.Params {
+	params := botV5.Params{"chat_id": strconv.FormatInt(c.ChatID, 10)}
+	params.AddNonZero("message_thread_id", c.ThreadID)
+	return params
+}
+
+// splitLog splits a log message into several messages of at most limit characters in the parse mode.
+// Every message carries its own code block, so the formatting survives the split;
+// the header is only included in the first message.
+func splitLog(mode ParseMode, header, body string, limit int) []logMessage {
+	header = truncateText(header, limit/2, textLength)
+
+	var msgs []logMessage
+	first := limit - textLength(logMessage{header: header}.render(mode))
+	rest := limit - textLength(logMessage{}.render(mode))
+	for _, piece := range splitText(body, first, rest, mode.codeLength) {
+		msgs = append(msgs, logMessage{header: header, body: piece})
+		header = ""
+	}
+	return msgs
+}
+
+// summarizeLog builds a message of at most limit characters in the parse mode with the header
+// and the beginning of the body, to be sent before the full body as a document.
+func summarizeLog(mode ParseMode, header, body string, limit int) logMessage {
+	msg := logMessage{
+		header: truncateText(header, limit/2, textLength),
+		footer: "\nFull log is attached as log.json.",
+	}
+
+	budget := limit - textLength(msg.render(mode))
+	msg.body = truncateText(body, budget, mode.codeLength)
+	return msg
+}
+
+// splitText splits text into pieces, preferring line boundaries, measuring them with length.
+// The first piece is at most first long, the remaining pieces at most rest.
+func splitText(text string, first, rest int, length func(string) int) []string {
+	var pieces []string
+	var piece strings.Builder
+	limit := first
+
+	flush := func() {
+		pieces = append(pieces, strings.TrimSuffix(piece.String(), "\n"))
+		piece.Reset()
+		limit = rest
+	}
+
+	for _, line := range strings.SplitAfter(text, "\n") {
+		for line != "" {
+			free := limit - length(piece.String())
+			if length(line) <= free {
+				piece.WriteString(line)
+				break
+			}
+
+			if piece.Len() > 0 {
+				flush()
+				continue
+			}
+
+			head := truncateText(line, free, length)
+			piece.WriteString(head)
+			line = line[len(head):]
+			flush()
+		}
+	}
+
+	if piece.Len() > 0 || len(pieces) == 0 {
+		flush()
+	}
+	return pieces
+}
+
+// truncateText returns the longest prefix of text that is at most limit long, measured with length.
+func truncateText(text string, limit int, length func(string) int) string {
+	n := 0
+	for i, r := range text {
+		n += length(string(r))
+		if n > limit {
+			return text[:i]
+		}
+	}
+	return text
+}
+
+// textLength returns the length of text as counted by Telegram, in UTF-16 code units.
+func textLength(text string) int {
+	n := 0
+	for _, r := range text {
+		n += runeLength(r)
+	}
+	return n
+}
+
+// runeLength returns the number of UTF-16 code units needed to encode r.
+func runeLength(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
 }
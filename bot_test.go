@@ -0,0 +1,305 @@
+package errs
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const fakeBotToken = "123:token"
+
+// fakeRequest is a Bot API request received by fakeBotAPI.
+type fakeRequest struct {
+	Method string
+	Params url.Values
+}
+
+// fakeBotAPI is an in-process Bot API server that records the requests it receives.
+type fakeBotAPI struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []fakeRequest
+	updates  []map[string]any // Updates returned by the next getUpdates request.
+	// fail returns a non-empty description to reject a request with a 400 error.
+	fail func(req fakeRequest) string
+	// respond returns the result of a request, or nil for the default result.
+	respond func(req fakeRequest) any
+}
+
+// newFakeBotAPI starts a fake Bot API server that is closed when the test ends.
+func newFakeBotAPI(t *testing.T) *fakeBotAPI {
+	f := &fakeBotAPI{}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeBotAPI) serve(w http.ResponseWriter, r *http.Request) {
+	_ = r.ParseMultipartForm(1 << 20)
+	req := fakeRequest{
+		Method: strings.TrimPrefix(r.URL.Path, "/bot"+fakeBotToken+"/"),
+		Params: r.Form,
+	}
+
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	messageID := len(f.requests)
+	fail := f.fail
+	f.mu.Unlock()
+
+	if fail != nil {
+		if description := fail(req); description != "" {
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": false, "error_code": 400, "description": description})
+			return
+		}
+	}
+
+	var result any = map[string]any{"message_id": messageID, "date": time.Now().Unix()}
+	switch req.Method {
+	case "getMe":
+		result = map[string]any{"id": 123, "is_bot": true, "username": "errs_bot"}
+	case "getUpdates":
+		result = f.takeUpdates(r)
+	}
+	if f.respond != nil {
+		if custom := f.respond(req); custom != nil {
+			result = custom
+		}
+	}
+	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": result})
+}
+
+// addUpdate queues an update for the next getUpdates request.
+func (f *fakeBotAPI) addUpdate(u map[string]any) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	u["update_id"] = len(f.updates) + 1
+	f.updates = append(f.updates, u)
+}
+
+// takeUpdates returns the queued updates, waiting briefly for new ones like a long poll.
+func (f *fakeBotAPI) takeUpdates(r *http.Request) []map[string]any {
+	for {
+		f.mu.Lock()
+		updates := f.updates
+		f.updates = nil
+		f.mu.Unlock()
+
+		if len(updates) > 0 {
+			return updates
+		}
+
+		select {
+		case <-r.Context().Done():
+			return nil
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+// waitCalls waits until the fake received n requests for the Bot API method.
+func (f *fakeBotAPI) waitCalls(t *testing.T, method string, n int) []fakeRequest {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if calls := f.calls(method); len(calls) >= n {
+			return calls
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("Expected %d %s request(s), got %d", n, method, len(f.calls(method)))
+	return nil
+}
+
+// calls returns the requests received for the Bot API method.
+func (f *fakeBotAPI) calls(method string) []fakeRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var calls []fakeRequest
+	for _, req := range f.requests {
+		if req.Method == method {
+			calls = append(calls, req)
+		}
+	}
+	return calls
+}
+
+// setupFakeBot configures the package bot against the fake server and removes it when the test ends.
+func setupFakeBot(t *testing.T, f *fakeBotAPI, params BroadcastBotParams) {
+	params.Token = fakeBotToken
+	params.APIEndpoint = f.URL
+	if params.ServiceName == "" {
+		params.ServiceName = "test_service"
+	}
+
+	if err := NewBroadcastBot(params); err != nil {
+		t.Fatal("Expected no error, got", err)
+	}
+	t.Cleanup(func() {
+		if old := Default().SetBot(nil); old != nil {
+			old.Close()
+		}
+	})
+}
+
+func TestNewBroadcastBot_Endpoint(t *testing.T) {
+	f := newFakeBotAPI(t)
+	setupFakeBot(t, f, BroadcastBotParams{ChatIDs: []int64{1}})
+
+	if len(f.calls("getMe")) != 1 {
+		t.Fatal("Expected the token to be checked with getMe")
+	}
+
+	Default().getLogger(Entry{Time: time.Now(), Level: slog.LevelError, Message: "failed", ErrorPath: "cause"})
+
+	sent := f.calls("sendMessage")
+	if len(sent) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(sent))
+	}
+	if sent[0].Params.Get("chat_id") != "1" || !strings.Contains(sent[0].Params.Get("text"), "cause") {
+		t.Fatalf("Unexpected message %v", sent[0].Params)
+	}
+}
+
+func TestNewBroadcastBot_Lazy(t *testing.T) {
+	f := newFakeBotAPI(t)
+	setupFakeBot(t, f, BroadcastBotParams{ChatIDs: []int64{1}, Lazy: true})
+
+	if len(f.calls("getMe")) != 0 {
+		t.Fatal("Expected a lazy bot not to call getMe")
+	}
+}
+
+func TestNewBroadcastBot_InvalidParams(t *testing.T) {
+	if err := NewBroadcastBot(BroadcastBotParams{ChatIDs: []int64{1}}); err == nil {
+		t.Fatal("Expected an error without a token")
+	}
+
+	if err := NewBroadcastBot(BroadcastBotParams{Token: fakeBotToken}); err == nil {
+		t.Fatal("Expected an error without chats")
+	}
+}
+
+func TestBroadcastBot_ParseErrorFallback(t *testing.T) {
+	f := newFakeBotAPI(t)
+	f.fail = func(req fakeRequest) string {
+		if req.Params.Get("parse_mode") != "" {
+			return "Bad Request: can't parse entities: Can't find end of the entity"
+		}
+		return ""
+	}
+	setupFakeBot(t, f, BroadcastBotParams{ServiceName: "my_service", ChatIDs: []int64{1}, ParseMode: ParseModeMarkdownV2, Lazy: true})
+
+	Default().getLogger(Entry{Time: time.Now(), Level: slog.LevelError, Message: "failed"})
+
+	sent := f.calls("sendMessage")
+	if len(sent) != 2 {
+		t.Fatalf("Expected the message to be resent, got %d request(s)", len(sent))
+	}
+	if sent[1].Params.Get("parse_mode") != "" || !strings.HasPrefix(sent[1].Params.Get("text"), "Service Name: my_service") {
+		t.Fatalf("Expected the message to be resent as plain text, got %v", sent[1].Params)
+	}
+}
+
+func TestBroadcastBot_RouteToTopic(t *testing.T) {
+	f := newFakeBotAPI(t)
+	setupFakeBot(t, f, BroadcastBotParams{
+		ChatIDs: []int64{1},
+		Routes:  []Route{{Codes: []string{"payment"}, Chats: []ChatTarget{{ChatID: 2, ThreadID: 5}}}},
+		Lazy:    true,
+	})
+
+	Default().getLogger(Entry{Time: time.Now(), Level: slog.LevelError, Code: "payment"})
+
+	sent := f.calls("sendMessage")
+	if len(sent) != 1 || sent[0].Params.Get("chat_id") != "2" || sent[0].Params.Get("message_thread_id") != "5" {
+		t.Fatalf("Expected the message to be sent to the payments topic, got %v", sent)
+	}
+}
+
+func TestBroadcastBot_LongMessageDocument(t *testing.T) {
+	f := newFakeBotAPI(t)
+	setupFakeBot(t, f, BroadcastBotParams{ChatIDs: []int64{1}, LongMessages: LongMessageDocument, Lazy: true})
+
+	Default().getLogger(Entry{Time: time.Now(), Level: slog.LevelError, Request: strings.Repeat("x", 2*maxMessageLength)})
+
+	if len(f.calls("sendMessage")) != 1 {
+		t.Fatal("Expected a summary message")
+	}
+	if len(f.calls("sendDocument")) != 1 {
+		t.Fatal("Expected the full log as a document")
+	}
+}
+
+var parseModes = []ParseMode{"", ParseModeMarkdown, ParseModeMarkdownV2, ParseModeHTML, ParseModeText}
+
+func TestSplitLog(t *testing.T) {
+	header := "Service Name: test_service\nT: now\n"
+	body := strings.Repeat(`  "key": "<value> & `+"`code`\",\n", 1000)
+
+	for _, mode := range parseModes {
+		msgs := splitLog(mode, header, body, maxMessageLength)
+		if len(msgs) < 2 {
+			t.Fatalf("%q: expected the log to be split, got %d message(s)", mode, len(msgs))
+		}
+
+		if msgs[0].header != header {
+			t.Fatalf("%q: expected the first message to carry the header", mode)
+		}
+
+		var joined strings.Builder
+		for i, msg := range msgs {
+			if n := textLength(msg.render(mode)); n > maxMessageLength {
+				t.Fatalf("%q: message %d is %d characters long", mode, i, n)
+			}
+			if i > 0 && msg.header != "" {
+				t.Fatalf("%q: expected only the first message to carry the header", mode)
+			}
+			joined.WriteString(msg.body + "\n")
+		}
+
+		if joined.String() != body {
+			t.Fatalf("%q: expected the split messages to contain the whole body", mode)
+		}
+	}
+}
+
+func TestSplitLog_LongLine(t *testing.T) {
+	body := strings.Repeat("𝄞", maxMessageLength)
+
+	for _, mode := range parseModes {
+		msgs := splitLog(mode, "header\n", body, maxMessageLength)
+		for i, msg := range msgs {
+			if n := textLength(msg.render(mode)); n > maxMessageLength {
+				t.Fatalf("%q: message %d is %d characters long", mode, i, n)
+			}
+		}
+	}
+}
+
+func TestSummarizeLog(t *testing.T) {
+	header := strings.Repeat("h", maxMessageLength)
+	body := strings.Repeat("<b>", maxMessageLength)
+
+	for _, mode := range parseModes {
+		summary := summarizeLog(mode, header, body, maxMessageLength)
+		if n := textLength(summary.render(mode)); n > maxMessageLength {
+			t.Fatalf("%q: expected the summary to fit into one message, got %d characters", mode, n)
+		}
+
+		if !strings.Contains(summary.render(ParseModeText), "log.json") {
+			t.Fatalf("%q: expected the summary to mention the attached document", mode)
+		}
+	}
+}
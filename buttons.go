@@ -0,0 +1,183 @@
+package errs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	botV5 "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Callback data of the alert buttons, followed by ":" and the fingerprint.
+const (
+	callbackAck    = "ack"
+	callbackMute1h = "mute1h"
+	callbackMute1d = "mute24h"
+)
+
+// maxCallbackData is the maximum length in bytes of the callback data of a Telegram button.
+const maxCallbackData = 64
+
+// maxCallbackKeys is the number of long fingerprints remembered for their buttons.
+// Buttons of older alerts with a long fingerprint fall back to the key itself.
+const maxCallbackKeys = 1024
+
+// callbackKeys maps the short keys used in the buttons of alerts with a long fingerprint
+// back to the fingerprint.
+var callbackKeys = struct {
+	sync.Mutex
+	fingerprints map[string]string
+	order        []string
+}{fingerprints: map[string]string{}}
+
+// alertKeyboard returns the Ack and Mute buttons attached to an alert for the fingerprint.
+func alertKeyboard(fingerprint string) *botV5.InlineKeyboardMarkup {
+	key := callbackKey(fingerprint)
+	keyboard := botV5.NewInlineKeyboardMarkup(botV5.NewInlineKeyboardRow(
+		botV5.NewInlineKeyboardButtonData("Ack", callbackAck+":"+key),
+		botV5.NewInlineKeyboardButtonData("Mute 1h", callbackMute1h+":"+key),
+		botV5.NewInlineKeyboardButtonData("Mute 24h", callbackMute1d+":"+key),
+	))
+	return &keyboard
+}
+
+// callbackKey returns the fingerprint as used in the callback data of the buttons.
+// A fingerprint too long for the callback data is replaced by a short hash of it,
+// remembered so that callbackFingerprint maps it back.
+func callbackKey(fingerprint string) string {
+	if len(callbackMute1d)+1+len(fingerprint) <= maxCallbackData {
+		return fingerprint
+	}
+
+	sum := sha256.Sum256([]byte(fingerprint))
+	key := "#" + hex.EncodeToString(sum[:8])
+
+	callbackKeys.Lock()
+	defer callbackKeys.Unlock()
+
+	if _, ok := callbackKeys.fingerprints[key]; !ok {
+		if len(callbackKeys.order) >= maxCallbackKeys {
+			delete(callbackKeys.fingerprints, callbackKeys.order[0])
+			callbackKeys.order = callbackKeys.order[1:]
+		}
+		callbackKeys.order = append(callbackKeys.order, key)
+	}
+	callbackKeys.fingerprints[key] = fingerprint
+	return key
+}
+
+// callbackFingerprint returns the fingerprint of the callback data key of a button.
+func callbackFingerprint(key string) string {
+	callbackKeys.Lock()
+	defer callbackKeys.Unlock()
+
+	if fp, ok := callbackKeys.fingerprints[key]; ok {
+		return fp
+	}
+	return key
+}
+
+// handleCallback handles a press on an alert button: it mutes the fingerprint if asked to,
+// and edits the alert to show who pressed the button and when.
+func (bb *BroadcastBot) handleCallback(ctx context.Context, cq *botV5.CallbackQuery, now time.Time) error {
+	m := cq.Message
+	if m == nil || m.Chat == nil || !bb.isBotChat(m.Chat.ID) {
+		return nil
+	}
+
+	action, key, ok := strings.Cut(cq.Data, ":")
+	if !ok || key == "" {
+		return nil
+	}
+	fp := callbackFingerprint(key)
+
+	var note string
+	switch action {
+	case callbackAck:
+		note = "Acknowledged"
+	case callbackMute1h:
+		Mute(fp, time.Hour)
+		note = "Muted for 1h"
+	case callbackMute1d:
+		Mute(fp, 24*time.Hour)
+		note = "Muted for 24h"
+	default:
+		return nil
+	}
+
+	if err := bb.answerCallback(ctx, cq.ID, note); err != nil {
+		return err
+	}
+
+	note = fmt.Sprintf("%s by %s at %s", note, userName(cq.From), now.Format(time.RFC3339))
+	if bb.repeats != nil {
+		// Keep the note, so that later edits of the occurrence counter show it too.
+		bb.repeats.addNote(m.Chat.ID, m.MessageID, note)
+	}
+
+	// Append the note to the plain text and keep the original entities,
+	// so the formatting of the alert survives without re-rendering it.
+	params := botV5.Params{
+		"chat_id":    strconv.FormatInt(m.Chat.ID, 10),
+		"message_id": strconv.Itoa(m.MessageID),
+		"text":       m.Text + "\n\n" + note,
+	}
+	if len(m.Entities) > 0 {
+		if err := params.AddInterface("entities", m.Entities); err != nil {
+			return Wrap(err, "failed to encode message entities")
+		}
+	}
+	if err := params.AddInterface("reply_markup", m.ReplyMarkup); err != nil {
+		return Wrap(err, "failed to encode reply markup")
+	}
+
+	if _, err := bb.request(ctx, "editMessageText", params); err != nil {
+		return WrapF(err, "failed to edit message %d in chat %d", m.MessageID, m.Chat.ID)
+	}
+	return nil
+}
+
+// answerCallback confirms a button press with a short notification to the user.
+func (bb *BroadcastBot) answerCallback(ctx context.Context, id, text string) error {
+	_, err := bb.request(ctx, "answerCallbackQuery", botV5.Params{"callback_query_id": id, "text": text})
+	if err != nil {
+		return Wrap(err, "failed to answer callback query")
+	}
+	return nil
+}
+
+// isBotChat reports whether the bot sends alerts or accepts commands in the chat.
+func (bb *BroadcastBot) isBotChat(chatID int64) bool {
+	routing := bb.routes()
+	chats := append(bb.allChats(), routing.defaultRoute...)
+	for _, r := range routing.routes {
+		chats = append(chats, r.Chats...)
+	}
+	for _, id := range bb.commandChats {
+		chats = append(chats, ChatTarget{ChatID: id})
+	}
+
+	for _, chat := range chats {
+		if chat.ChatID == chatID {
+			return true
+		}
+	}
+	return false
+}
+
+// userName returns how a user is shown in an edited alert.
+func userName(u *botV5.User) string {
+	switch {
+	case u == nil:
+		return "unknown"
+	case u.UserName != "":
+		return "@" + u.UserName
+	default:
+		return strings.TrimSpace(u.FirstName + " " + u.LastName)
+	}
+}
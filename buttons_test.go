@@ -0,0 +1,109 @@
+package errs
+
+import (
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBroadcastBot_AlertButtons(t *testing.T) {
+	f := newFakeBotAPI(t)
+	setupFakeBot(t, f, BroadcastBotParams{ChatIDs: []int64{1}, Buttons: true, Lazy: true})
+
+	Default().getLogger(Entry{Time: time.Now(), Level: slog.LevelError, Fingerprint: "fp_buttons"})
+
+	sent := f.calls("sendMessage")
+	if len(sent) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(sent))
+	}
+
+	var markup struct {
+		InlineKeyboard [][]struct {
+			Text         string `json:"text"`
+			CallbackData string `json:"callback_data"`
+		} `json:"inline_keyboard"`
+	}
+	if err := json.Unmarshal([]byte(sent[0].Params.Get("reply_markup")), &markup); err != nil {
+		t.Fatal("Expected an inline keyboard, got", err)
+	}
+
+	var data []string
+	for _, button := range markup.InlineKeyboard[0] {
+		data = append(data, button.CallbackData)
+	}
+	if strings.Join(data, ",") != "ack:fp_buttons,mute1h:fp_buttons,mute24h:fp_buttons" {
+		t.Fatalf("Unexpected buttons %v", data)
+	}
+}
+
+func TestBroadcastBot_MuteButton(t *testing.T) {
+	t.Cleanup(func() { Unmute("fp_pressed") })
+
+	f := newFakeBotAPI(t)
+	f.addUpdate(callbackUpdate(99, "mute1h:fp_pressed"))
+	f.addUpdate(callbackUpdate(1, "mute1h:fp_pressed"))
+	setupFakeBot(t, f, BroadcastBotParams{ChatIDs: []int64{1}, Buttons: true, Lazy: true})
+
+	edits := f.waitCalls(t, "editMessageText", 1)
+	if !IsMuted("fp_pressed") {
+		t.Fatal("Expected the button to mute the fingerprint")
+	}
+
+	text := edits[0].Params.Get("text")
+	if !strings.HasPrefix(text, "Service Name: test\n") || !strings.Contains(text, "Muted for 1h by @alice at ") {
+		t.Fatalf("Unexpected edited text %q", text)
+	}
+	if edits[0].Params.Get("chat_id") != "1" || edits[0].Params.Get("message_id") != "42" {
+		t.Fatalf("Expected the original message to be edited, got %v", edits[0].Params)
+	}
+	if edits[0].Params.Get("entities") == "" || edits[0].Params.Get("reply_markup") == "" {
+		t.Fatal("Expected the formatting and the buttons to be kept")
+	}
+
+	if len(f.calls("answerCallbackQuery")) != 1 {
+		t.Fatal("Expected only the button pressed in a bot chat to be answered")
+	}
+}
+
+func TestAlertKeyboard_LongFingerprint(t *testing.T) {
+	fp := strings.Repeat("f", 100)
+	t.Cleanup(func() { Unmute(fp) })
+
+	keyboard := alertKeyboard(fp)
+	for _, button := range keyboard.InlineKeyboard[0] {
+		if len(*button.CallbackData) > maxCallbackData {
+			t.Fatalf("Expected callback data of at most %d bytes, got %q", maxCallbackData, *button.CallbackData)
+		}
+	}
+
+	data := *keyboard.InlineKeyboard[0][1].CallbackData
+	f := newFakeBotAPI(t)
+	f.addUpdate(callbackUpdate(1, data))
+	setupFakeBot(t, f, BroadcastBotParams{ChatIDs: []int64{1}, Buttons: true, Lazy: true})
+
+	f.waitCalls(t, "editMessageText", 1)
+	if !IsMuted(fp) {
+		t.Fatal("Expected the button to mute the long fingerprint")
+	}
+}
+
+// callbackUpdate returns an update with a button press on an alert sent to the chat.
+func callbackUpdate(chatID int64, data string) map[string]any {
+	return map[string]any{
+		"callback_query": map[string]any{
+			"id":   "cb",
+			"from": map[string]any{"id": 7, "first_name": "Alice", "username": "alice"},
+			"data": data,
+			"message": map[string]any{
+				"message_id":   42,
+				"date":         time.Now().Unix(),
+				"chat":         map[string]any{"id": chatID, "type": "group"},
+				"text":         "Service Name: test\n{}",
+				"entities":     []map[string]any{{"type": "pre", "offset": 19, "length": 2}},
+				"reply_markup": alertKeyboard("fp_pressed"),
+			},
+		},
+	}
+}
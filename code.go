@@ -0,0 +1,36 @@
+package errs
+
+// WithCode attaches a machine-readable code to an error, for example "payment_declined".
+// The code is kept when the error is wrapped and is logged and routed along with it.
+//
+// Parameters:
+//   - err: The error to attach the code to. If this is nil, the function returns nil.
+//   - code: The code to attach. It replaces any code the error already has.
+//
+// Returns:
+//   - A new error with the same messages as err and the given code.
+func WithCode(err error, code string) error {
+	if err == nil {
+		return nil
+	}
+
+	return &errorString{
+		message: Unwrap(err),
+		origErr: err.Error(),
+		code:    code,
+		stack:   stackOrCallers(err, 1),
+		attrs:   errorAttrs(err),
+		causes:  []error{err},
+	}
+}
+
+// Code returns the code attached to an error with WithCode.
+// If the error is nil or has no code, it returns an empty string.
+func Code(err error) string {
+	e, ok := err.(*errorString)
+	if !ok || e == nil {
+		return ""
+	}
+
+	return e.code
+}
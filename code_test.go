@@ -0,0 +1,42 @@
+package errs
+
+import (
+	"testing"
+)
+
+func TestWithCode(t *testing.T) {
+	if WithCode(nil, "code") != nil {
+		t.Fatal("Expected WithCode(nil) to return nil")
+	}
+
+	err := WithCode(New("declined"), "payment_declined")
+	if Code(err) != "payment_declined" {
+		t.Fatalf("Expected code 'payment_declined', got '%s'", Code(err))
+	}
+
+	wrapped := Wrap(err, "charge card")
+	if Code(wrapped) != "payment_declined" {
+		t.Fatalf("Expected the code to survive wrapping, got '%s'", Code(wrapped))
+	}
+
+	if Unwrap(wrapped) != "charge card ---> declined" {
+		t.Fatalf("Expected the message chain to be kept, got '%s'", Unwrap(wrapped))
+	}
+}
+
+func TestCode_NoCode(t *testing.T) {
+	if Code(nil) != "" {
+		t.Fatal("Expected an empty code for a nil error")
+	}
+
+	if Code(New("plain")) != "" {
+		t.Fatal("Expected an empty code for an error without code")
+	}
+}
+
+func TestJoin_Code(t *testing.T) {
+	err := Join(", ", New("first"), WithCode(New("second"), "second_code"), WithCode(New("third"), "third_code"))
+	if Code(err) != "second_code" {
+		t.Fatalf("Expected the first code of the joined errors, got '%s'", Code(err))
+	}
+}
@@ -0,0 +1,213 @@
+package errs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	botV5 "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	pollTimeout       = 30 * time.Second // Long-polling timeout of getUpdates, unless the HTTP client times out sooner.
+	pollRetryInterval = 5 * time.Second  // Pause after a failed getUpdates request, or between requests without long polling.
+	defaultMuteTime   = time.Hour        // Mute duration of /mute without a duration.
+	maxStatsLines     = 20               // Number of fingerprints listed by /stats.
+)
+
+// commandHelp is the reply to unknown commands.
+const commandHelp = `Commands:
+/status - sink health and queue depth
+/stats - errors by fingerprint over the last hour
+/mute <fingerprint> [duration] - mute a fingerprint, 1h by default
+/unmute <fingerprint> - lift a mute`
+
+// pollUpdates long-polls the Bot API for commands and button presses until the bot is closed.
+func (bb *BroadcastBot) pollUpdates() {
+	offset := 0
+	for {
+		updates, err := bb.getUpdates(bb.ctx, offset)
+		if bb.ctx.Err() != nil {
+			return
+		}
+
+		// Requests that do not wait for updates are paused between, like failed ones.
+		if err != nil || (len(updates) == 0 && bb.pollWait < time.Second) {
+			reportPollError(err)
+			select {
+			case <-time.After(pollRetryInterval):
+			case <-bb.ctx.Done():
+				return
+			}
+			continue
+		}
+
+		for _, u := range updates {
+			offset = u.UpdateID + 1
+			reportBotError(bb.handleUpdate(bb.ctx, u))
+		}
+	}
+}
+
+// longPollTimeout returns how long getUpdates waits for new updates with an HTTP client:
+// pollTimeout, or half the timeout of the client if it is shorter, so that requests waiting
+// for updates do not time out.
+func longPollTimeout(client *http.Client) time.Duration {
+	if client == nil || client.Timeout <= 0 {
+		return pollTimeout
+	}
+	return min(pollTimeout, client.Timeout/2)
+}
+
+// reportPollError logs a failure to get the updates of a bot using all configured loggers.
+// It does nothing if err is nil.
+func reportPollError(err error) {
+	if err == nil {
+		return
+	}
+
+	go logInternal("Failed to get updates from Telegram", "error", Unwrap(err))
+}
+
+// getUpdates requests the updates following offset, waiting up to the long-polling timeout
+// of the bot for new ones, see longPollTimeout.
+func (bb *BroadcastBot) getUpdates(ctx context.Context, offset int) ([]botV5.Update, error) {
+	params := botV5.Params{
+		"timeout":         strconv.Itoa(int(bb.pollWait.Seconds())),
+		"allowed_updates": `["message","callback_query"]`,
+	}
+	params.AddNonZero("offset", offset)
+
+	result, err := bb.request(ctx, "getUpdates", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var updates []botV5.Update
+	if err := json.Unmarshal(result, &updates); err != nil {
+		return nil, Wrap(err, "failed to decode telegram updates")
+	}
+	return updates, nil
+}
+
+// handleUpdate answers a command sent from an authorized chat or handles a button press.
+// Other updates are ignored.
+func (bb *BroadcastBot) handleUpdate(ctx context.Context, u botV5.Update) error {
+	if u.CallbackQuery != nil && bb.buttons {
+		return bb.handleCallback(ctx, u.CallbackQuery, time.Now())
+	}
+
+	m := u.Message
+	if !bb.commands || m == nil || m.Chat == nil || !m.IsCommand() || !slices.Contains(bb.commandChats, m.Chat.ID) {
+		return nil
+	}
+
+	params := ChatTarget{ChatID: m.Chat.ID}.params()
+	params["text"] = runCommand(m.Command(), m.CommandArguments(), time.Now())
+	params.AddNonZero("reply_to_message_id", m.MessageID)
+
+	if _, err := bb.request(ctx, "sendMessage", params); err != nil {
+		return WrapF(err, "failed to answer command in chat %d", m.Chat.ID)
+	}
+	return nil
+}
+
+// runCommand executes a bot command and returns the plain text reply.
+func runCommand(command, args string, now time.Time) string {
+	fields := strings.Fields(args)
+
+	switch command {
+	case "status":
+		return statusText(now)
+	case "stats":
+		return statsText(now)
+	case "mute":
+		if len(fields) == 0 {
+			return "Usage: /mute <fingerprint> [duration]"
+		}
+
+		d := defaultMuteTime
+		if len(fields) > 1 {
+			var err error
+			if d, err = time.ParseDuration(fields[1]); err != nil || d <= 0 {
+				return fmt.Sprintf("Invalid duration %q, use for example 30m or 24h.", fields[1])
+			}
+		}
+
+		Mute(fields[0], d)
+		return fmt.Sprintf("Muted %s until %s.", fields[0], now.Add(d).Format(time.RFC3339))
+	case "unmute":
+		if len(fields) == 0 {
+			return "Usage: /unmute <fingerprint>"
+		}
+
+		Unmute(fields[0])
+		return fmt.Sprintf("Unmuted %s.", fields[0])
+	default:
+		return commandHelp
+	}
+}
+
+// statusText describes the sink health, the queue depth and the active mutes.
+func statusText(now time.Time) string {
+	status := GetStatus()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Queue depth: %d\n", status.QueueDepth)
+
+	b.WriteString("Sinks:\n")
+	if len(status.Sinks) == 0 {
+		b.WriteString("  no deliveries yet\n")
+	}
+	for _, s := range status.Sinks {
+		fmt.Fprintf(&b, "  %s: %d delivered, %d failed", s.Name, s.Delivered, s.Failed)
+		if s.Suppressed > 0 {
+			fmt.Fprintf(&b, ", %d suppressed", s.Suppressed)
+		}
+		if s.Retried > 0 {
+			fmt.Fprintf(&b, ", %d retried", s.Retried)
+		}
+		if s.LastError != "" {
+			fmt.Fprintf(&b, ", last error %s ago: %s", now.Sub(s.LastErrorAt).Round(time.Second), s.LastError)
+		}
+		b.WriteString("\n")
+	}
+
+	active := Mutes()
+	fps := make([]string, 0, len(active))
+	for fp := range active {
+		fps = append(fps, fp)
+	}
+	slices.Sort(fps)
+
+	fmt.Fprintf(&b, "Mutes: %d\n", len(fps))
+	for _, fp := range fps {
+		fmt.Fprintf(&b, "  %s until %s\n", fp, active[fp].Format(time.RFC3339))
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// statsText lists the most frequent fingerprints of the last hour.
+func statsText(now time.Time) string {
+	counts := errorStats.top(now)
+	if len(counts) == 0 {
+		return "No errors in the last hour."
+	}
+
+	var b strings.Builder
+	b.WriteString("Errors in the last hour:")
+	for i, c := range counts {
+		if i == maxStatsLines {
+			fmt.Fprintf(&b, "\n... and %d more", len(counts)-i)
+			break
+		}
+		fmt.Fprintf(&b, "\n%s ×%d %s", c.Fingerprint, c.Count, truncateText(c.ErrorPath, 80, textLength))
+	}
+	return b.String()
+}
@@ -0,0 +1,117 @@
+package errs
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunCommand_Mute(t *testing.T) {
+	t.Cleanup(func() { Unmute("fp_mute") })
+
+	reply := runCommand("mute", "fp_mute 24h", time.Now())
+	if !strings.HasPrefix(reply, "Muted fp_mute") {
+		t.Fatalf("Unexpected reply %q", reply)
+	}
+	if until := Mutes()["fp_mute"]; time.Until(until) < 23*time.Hour {
+		t.Fatalf("Expected a 24h mute, got one until %v", until)
+	}
+
+	if reply := runCommand("mute", "fp_mute soon", time.Now()); !strings.HasPrefix(reply, "Invalid duration") {
+		t.Fatalf("Expected an invalid duration to be rejected, got %q", reply)
+	}
+
+	runCommand("unmute", "fp_mute", time.Now())
+	if IsMuted("fp_mute") {
+		t.Fatal("Expected /unmute to lift the mute")
+	}
+}
+
+func TestRunCommand_Stats(t *testing.T) {
+	resetStats(t)
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		errorStats.record(Entry{Time: now, Fingerprint: "fp_stats", ErrorPath: "db ---> timeout"})
+	}
+
+	if reply := runCommand("stats", "", now); !strings.Contains(reply, "fp_stats ×3 db ---> timeout") {
+		t.Fatalf("Expected the fingerprint to be counted, got %q", reply)
+	}
+}
+
+func TestRunCommand_Status(t *testing.T) {
+	if reply := runCommand("status", "", time.Now()); !strings.HasPrefix(reply, "Queue depth:") {
+		t.Fatalf("Unexpected reply %q", reply)
+	}
+
+	if reply := runCommand("help", "", time.Now()); reply != commandHelp {
+		t.Fatalf("Expected the help text, got %q", reply)
+	}
+}
+
+func TestBroadcastBot_Commands(t *testing.T) {
+	t.Cleanup(func() { Unmute("fp_polled") })
+
+	f := newFakeBotAPI(t)
+	f.addUpdate(commandUpdate(99, "/mute fp_polled"))
+	f.addUpdate(commandUpdate(1, "/mute@errs_bot fp_polled 2h"))
+	setupFakeBot(t, f, BroadcastBotParams{ChatIDs: []int64{1}, Commands: true, Lazy: true})
+
+	replies := f.waitCalls(t, "sendMessage", 1)
+	if replies[0].Params.Get("chat_id") != "1" || !strings.HasPrefix(replies[0].Params.Get("text"), "Muted fp_polled") {
+		t.Fatalf("Unexpected reply %v", replies[0].Params)
+	}
+
+	if !IsMuted("fp_polled") {
+		t.Fatal("Expected the command to mute the fingerprint")
+	}
+}
+
+func TestLongPollTimeout(t *testing.T) {
+	tests := []struct {
+		client *http.Client
+		want   time.Duration
+	}{
+		{nil, pollTimeout},
+		{&http.Client{}, pollTimeout},
+		{&http.Client{Timeout: time.Minute}, pollTimeout},
+		{&http.Client{Timeout: 10 * time.Second}, 5 * time.Second},
+		{&http.Client{Timeout: time.Second}, 500 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := longPollTimeout(tt.client); got != tt.want {
+			t.Errorf("longPollTimeout(%v) = %v, want %v", tt.client, got, tt.want)
+		}
+	}
+}
+
+func TestBroadcastBot_CommandsClientTimeout(t *testing.T) {
+	f := newFakeBotAPI(t)
+	f.addUpdate(commandUpdate(1, "/status"))
+	setupFakeBot(t, f, BroadcastBotParams{
+		ChatIDs:    []int64{1},
+		Commands:   true,
+		Lazy:       true,
+		HTTPClient: &http.Client{Timeout: 4 * time.Second},
+	})
+
+	f.waitCalls(t, "sendMessage", 1)
+	if polls := f.calls("getUpdates"); polls[0].Params.Get("timeout") != "2" {
+		t.Fatalf("Expected the long poll to end before the client times out, got %v", polls[0].Params)
+	}
+}
+
+// commandUpdate returns an update with a command message sent to the chat.
+func commandUpdate(chatID int64, text string) map[string]any {
+	command := strings.Fields(text)[0]
+	return map[string]any{
+		"message": map[string]any{
+			"message_id": 10,
+			"date":       time.Now().Unix(),
+			"chat":       map[string]any{"id": chatID, "type": "group"},
+			"text":       text,
+			"entities":   []map[string]any{{"type": "bot_command", "offset": 0, "length": len(command)}},
+		},
+	}
+}
@@ -0,0 +1,327 @@
+package errs
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"reflect"
+	"regexp"
+	"sync"
+	"syscall"
+)
+
+// Config is the runtime configuration of the default logger. CurrentConfig returns it
+// and ApplyConfig changes it while errors are being logged, for example from ConfigHandler
+// or from a file reloaded by ReloadOnSIGHUP.
+type Config struct {
+	ServiceName string     `json:"service_name"`       // Service name of the entries, see NewBroadcastBot.
+	LogTypes    []LogType  `json:"log_types"`          // Loggers to write to, see SetLogTypes.
+	LogFile     string     `json:"log_file,omitempty"` // Path of the file logger, see SetLogFile. Empty keeps the current file.
+	Level       slog.Level `json:"level"`              // Minimum level written by the loggers, see SetLevel.
+	Separator   string     `json:"separator"`          // Separator of the messages, see SetSupervisorErr.
+
+	// Routes and DefaultRoute replace the routes of the bot of the default logger,
+	// see BroadcastBot.SetRoutes. Nil keeps the current ones.
+	Routes       []RouteConfig `json:"routes,omitempty"`
+	DefaultRoute []ChatTarget  `json:"default_route,omitempty"`
+}
+
+// RouteConfig is a Route that can be written as JSON. Routes with a Match function
+// or another Bot cannot be expressed as a RouteConfig.
+type RouteConfig struct {
+	Levels    []slog.Level      `json:"levels,omitempty"`
+	Codes     []string          `json:"codes,omitempty"`
+	Services  []string          `json:"services,omitempty"`
+	Fields    map[string]string `json:"fields,omitempty"`
+	Message   string            `json:"message,omitempty"` // Regular expression, see Route.Message.
+	Chats     []ChatTarget      `json:"chats"`
+	CopyToAll bool              `json:"copy_to_all,omitempty"`
+	Template  string            `json:"template,omitempty"`
+}
+
+// CurrentConfig returns the configuration of the default logger.
+// The routes are only included when the default logger has a bot and
+// every route of the bot can be expressed as a RouteConfig.
+//
+// Returns:
+//   - The current configuration. Changing it has no effect until it is passed to ApplyConfig.
+func CurrentConfig() Config {
+	s := currentSettings()
+	c := Config{
+		ServiceName: s.service,
+		LogTypes:    append([]LogType{}, s.logTypes...),
+		Level:       s.level,
+		Separator:   s.separator,
+	}
+	if s.logFile != nil {
+		c.LogFile = s.logFile.Name()
+	}
+
+	if bot := Default().Bot(); bot != nil {
+		routing := bot.routes()
+		if routes, ok := routeConfigs(routing.routes); ok {
+			c.Routes = routes
+			c.DefaultRoute = append([]ChatTarget{}, routing.defaultRoute...)
+		}
+	}
+	return c
+}
+
+// ApplyConfig replaces the configuration of the default logger. It is safe to call while
+// errors are being logged: entries already being logged finish with the sinks they started
+// with, and a replaced log file is closed once nothing writes to it anymore.
+// Every field is applied, so start from CurrentConfig to keep the fields that do not change.
+//
+// Parameters:
+//   - c: The configuration to apply.
+//
+// Returns:
+//   - An error if the configuration is invalid or the log file cannot be opened,
+//     in which case nothing is changed.
+func ApplyConfig(c Config) error {
+	return applyConfig(c, nil, nil)
+}
+
+// applyConfig applies c like ApplyConfig. A non-nil rotation replaces the rotation of the log
+// file, and a non-nil bot replaces the bot of the default logger, which is then closed. Nothing
+// is replaced until every step that can fail has succeeded; on failure the new log file and
+// the new bot are closed.
+func applyConfig(c Config, rotation *RotationParams, bot *BroadcastBot) (err error) {
+	var file *logFile
+	defer func() {
+		if err == nil {
+			return
+		}
+		if file != nil {
+			_ = file.Close()
+		}
+		if bot != nil {
+			bot.Close()
+		}
+	}()
+
+	for i, t := range c.LogTypes {
+		switch t {
+		case LogTypeJSON, LogTypeText, LogTypeFile:
+		default:
+			return NewF("log_types[%d]: unknown log type %q", i, t)
+		}
+	}
+
+	routes, err := c.routes()
+	if err != nil {
+		return err
+	}
+	target := bot
+	if target == nil {
+		target = Default().Bot()
+	}
+	if target == nil && (c.Routes != nil || c.DefaultRoute != nil) {
+		return New("routes: the default logger has no bot")
+	}
+
+	s := currentSettings()
+	if rotation == nil {
+		rotation = &s.rotation
+	}
+	if c.LogFile != "" && (s.logFile == nil || s.logFile.Name() != c.LogFile) {
+		if file, err = openLogFile(c.LogFile, *rotation); err != nil {
+			return Wrap(err, "log_file")
+		}
+	}
+
+	if target != nil && (c.Routes != nil || c.DefaultRoute != nil) {
+		routing := target.routes()
+		if c.Routes == nil {
+			routes = routing.routes
+		}
+		defaultRoute := c.DefaultRoute
+		if defaultRoute == nil {
+			defaultRoute = routing.defaultRoute
+		}
+		if err := target.SetRoutes(routes, defaultRoute); err != nil {
+			return Wrap(err, "routes")
+		}
+	}
+
+	// Every step that can fail has succeeded, so swap in the new configuration.
+	if bot != nil {
+		if old := Default().SetBot(bot); old != nil {
+			old.Close()
+		}
+	}
+
+	var old *logFile
+	_ = updateSettings(func(s *settings) error {
+		s.service = c.ServiceName
+		s.logTypes = append([]LogType(nil), c.LogTypes...)
+		s.level = c.Level
+		s.separator = c.Separator
+		s.rotation = *rotation
+		if file != nil {
+			old, s.logFile = s.logFile, file
+		}
+		s.ensureLogFile(s.logTypes)
+		if s.logFile != nil {
+			s.logFile.setRotation(s.rotation)
+		}
+		return nil
+	})
+	old.retire()
+	return nil
+}
+
+// ApplyConfigFile reads a YAML or JSON configuration file like LoadConfig and applies the parts
+// that can change at runtime: the Config, the rotation and the throttles. The bot section is
+// ignored, since the bot is not recreated; the routes apply to the bot of the default logger.
+// Fields missing from the file keep their current values.
+//
+// Parameters:
+//   - path: The path of the configuration file, YAML unless it ends in .json.
+//
+// Returns:
+//   - An error if the file cannot be read, is not a valid configuration or cannot be applied.
+func ApplyConfigFile(path string) error {
+	s := currentSetup()
+	if err := readConfigFile(path, &s); err != nil {
+		return err
+	}
+
+	s.Bot = nil
+	if err := s.validate(); err != nil {
+		return err
+	}
+	return s.apply(s.Config, nil)
+}
+
+// ReloadOnSIGHUP applies the configuration file with ApplyConfigFile every time the process
+// receives SIGHUP, for example from "kill -HUP" or a Kubernetes config reloader.
+// A configuration that cannot be applied is logged and the current one is kept.
+//
+// Parameters:
+//   - path: The path of the configuration file.
+//
+// Returns:
+//   - A function that stops reloading. It is safe to call more than once.
+func ReloadOnSIGHUP(path string) (stop func()) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-signals:
+				if err := ApplyConfigFile(path); err != nil {
+					Log(err, path, "failed to reload the configuration")
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(signals)
+			close(done)
+		})
+	}
+}
+
+// ConfigHandler returns an http.Handler that serves the configuration of the default logger as JSON.
+//   - GET returns CurrentConfig.
+//   - PUT or POST applies the JSON body with ApplyConfig and returns the new configuration.
+//     Fields missing from the body keep their current values.
+//
+// The handler changes where errors are reported, so only expose it to trusted users.
+func ConfigHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			c, err := decodeConfig(r.Body)
+			if err == nil {
+				err = ApplyConfig(c)
+			}
+			if err != nil {
+				http.Error(w, Unwrap(err), http.StatusBadRequest)
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		writeDashboardJSON(w, CurrentConfig())
+	})
+}
+
+// decodeConfig decodes a JSON configuration over the current one, so that missing fields keep
+// their values. Routes are only replaced when they are given. Unknown fields are rejected.
+func decodeConfig(r io.Reader) (Config, error) {
+	c := CurrentConfig()
+	c.Routes, c.DefaultRoute = nil, nil
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return c, Wrap(err, "failed to read config")
+	}
+	var tree any
+	if err := unmarshalTree(data, &tree); err != nil {
+		return c, Wrap(err, "failed to decode config")
+	}
+	return c, decodeTree("", reflect.ValueOf(&c).Elem(), tree)
+}
+
+// routes converts the route configurations to routes, compiling their patterns.
+func (c Config) routes() ([]Route, error) {
+	routes := make([]Route, 0, len(c.Routes))
+	for i, rc := range c.Routes {
+		r := Route{
+			Levels:    rc.Levels,
+			Codes:     rc.Codes,
+			Services:  rc.Services,
+			Fields:    rc.Fields,
+			Chats:     rc.Chats,
+			CopyToAll: rc.CopyToAll,
+			Template:  rc.Template,
+		}
+		if rc.Message != "" {
+			var err error
+			if r.Message, err = regexp.Compile(rc.Message); err != nil {
+				return nil, WrapF(err, "routes[%d].message", i)
+			}
+		}
+		routes = append(routes, r)
+	}
+	return routes, nil
+}
+
+// routeConfigs converts routes to route configurations. It reports false
+// if a route has a Match function or another bot, which cannot be converted.
+func routeConfigs(routes []Route) ([]RouteConfig, bool) {
+	configs := make([]RouteConfig, 0, len(routes))
+	for _, r := range routes {
+		if r.Match != nil || r.Bot != nil {
+			return nil, false
+		}
+		rc := RouteConfig{
+			Levels:    r.Levels,
+			Codes:     r.Codes,
+			Services:  r.Services,
+			Fields:    r.Fields,
+			Chats:     r.Chats,
+			CopyToAll: r.CopyToAll,
+			Template:  r.Template,
+		}
+		if r.Message != nil {
+			rc.Message = r.Message.String()
+		}
+		configs = append(configs, rc)
+	}
+	return configs, true
+}
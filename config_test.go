@@ -0,0 +1,215 @@
+package errs
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// restoreConfig applies the current configuration again when the test ends.
+// The log file is kept, since the files of other tests may be removed already.
+func restoreConfig(t *testing.T) {
+	c := CurrentConfig()
+	c.LogFile, c.Routes, c.DefaultRoute = "", nil, nil
+	t.Cleanup(func() {
+		if err := ApplyConfig(c); err != nil {
+			t.Error("Expected the configuration to be restored, got", err)
+		}
+	})
+}
+
+func TestApplyConfig(t *testing.T) {
+	restoreConfig(t)
+	path := filepath.Join(t.TempDir(), "errors.json")
+
+	c := CurrentConfig()
+	c.LogTypes = []LogType{LogTypeFile}
+	c.LogFile = path
+	c.Level = slog.LevelWarn
+	c.Separator = " | "
+	if err := ApplyConfig(c); err != nil {
+		t.Fatal("Expected no error, got", err)
+	}
+
+	if got := CurrentConfig(); !reflect.DeepEqual(got.LogTypes, c.LogTypes) || got.LogFile != path || got.Level != slog.LevelWarn || got.Separator != " | " {
+		t.Fatalf("Expected the configuration to be applied, got %+v", got)
+	}
+	if got := Unwrap(Wrap(New("cause"), "context")); got != "context | cause" {
+		t.Fatalf("Expected the new separator, got %q", got)
+	}
+
+	Default().getLogger(Entry{Time: time.Now(), Level: slog.LevelWarn, Message: "warned"})
+	Default().getLogger(Entry{Time: time.Now(), Level: slog.LevelInfo, Message: "informed"})
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal("Expected no error, got", err)
+	}
+	if !strings.Contains(string(data), "warned") || strings.Contains(string(data), "informed") {
+		t.Fatalf("Expected only the warning in the new log file, got %s", data)
+	}
+
+	invalid := CurrentConfig()
+	invalid.LogTypes = []LogType{LogTypeJSON, "XML"}
+	invalid.Separator = " / "
+	if err := ApplyConfig(invalid); err == nil || !strings.Contains(err.Error(), "log_types[1]") {
+		t.Fatalf("Expected the invalid log type to be reported, got %v", err)
+	}
+	if CurrentConfig().Separator != " | " {
+		t.Fatal("Expected an invalid configuration not to change anything")
+	}
+}
+
+func TestApplyConfig_Routes(t *testing.T) {
+	restoreConfig(t)
+
+	c := CurrentConfig()
+	c.Routes = []RouteConfig{{Codes: []string{"config_test"}}}
+	if err := ApplyConfig(c); err == nil {
+		t.Fatal("Expected routes to need a bot")
+	}
+
+	f := newFakeBotAPI(t)
+	setupFakeBot(t, f, BroadcastBotParams{ChatIDs: []int64{1}, Lazy: true})
+
+	c = CurrentConfig()
+	c.Routes = []RouteConfig{{Codes: []string{"config_test"}, Message: "timeout", Chats: []ChatTarget{{ChatID: 2, ThreadID: 3}}}}
+	c.DefaultRoute = []ChatTarget{{ChatID: 4}}
+	if err := ApplyConfig(c); err != nil {
+		t.Fatal("Expected no error, got", err)
+	}
+
+	bot := Default().Bot()
+	if got := bot.route(Entry{Code: "config_test", Message: "timeout"}); !reflect.DeepEqual(got, []ChatTarget{{ChatID: 2, ThreadID: 3}}) {
+		t.Fatalf("Expected the new route, got %v", got)
+	}
+	if got := bot.route(Entry{Code: "other"}); !reflect.DeepEqual(got, []ChatTarget{{ChatID: 4}}) {
+		t.Fatalf("Expected the new default route, got %v", got)
+	}
+	if got := CurrentConfig(); !reflect.DeepEqual(got.Routes, c.Routes) || !reflect.DeepEqual(got.DefaultRoute, c.DefaultRoute) {
+		t.Fatalf("Expected the routes in the configuration, got %+v", got)
+	}
+
+	c.Routes = []RouteConfig{{Message: "("}}
+	if err := ApplyConfig(c); err == nil || !strings.Contains(Unwrap(err), "routes[0].message") {
+		t.Fatalf("Expected the invalid pattern to be reported, got %v", err)
+	}
+	c.Routes = []RouteConfig{{Template: "{{"}}
+	if err := ApplyConfig(c); err == nil {
+		t.Fatal("Expected the invalid template to be reported")
+	}
+	if got := bot.route(Entry{Code: "other"}); !reflect.DeepEqual(got, []ChatTarget{{ChatID: 4}}) {
+		t.Fatalf("Expected invalid routes not to change anything, got %v", got)
+	}
+
+	if err := bot.SetRoutes([]Route{{Match: func(Entry) bool { return true }}}, nil); err != nil {
+		t.Fatal("Expected no error, got", err)
+	}
+	if got := CurrentConfig(); got.Routes != nil {
+		t.Fatalf("Expected routes with a Match function to be left out, got %+v", got.Routes)
+	}
+}
+
+func TestConfigHandler(t *testing.T) {
+	restoreConfig(t)
+	srv := httptest.NewServer(ConfigHandler())
+	t.Cleanup(srv.Close)
+
+	put := func(body string) *http.Response {
+		req, err := http.NewRequest(http.MethodPut, srv.URL, strings.NewReader(body))
+		if err != nil {
+			t.Fatal("Expected no error, got", err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal("Expected no error, got", err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	before := CurrentConfig()
+	resp := put(`{"separator": " :: ", "level": "WARN"}`)
+	var got Config
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected the new configuration, got %d %v", resp.StatusCode, err)
+	}
+	if got.Separator != " :: " || got.Level != slog.LevelWarn || !reflect.DeepEqual(got.LogTypes, before.LogTypes) {
+		t.Fatalf("Expected only the given fields to change, got %+v", got)
+	}
+
+	for _, body := range []string{`{"log_types": ["XML"]}`, `{"unknown": true}`, `{`} {
+		if resp := put(body); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", body, resp.StatusCode)
+		}
+	}
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal("Expected no error, got", err)
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil || got.Separator != " :: " {
+		t.Fatalf("Expected the current configuration, got %+v %v", got, err)
+	}
+}
+
+func TestReloadOnSIGHUP(t *testing.T) {
+	restoreConfig(t)
+	path := filepath.Join(t.TempDir(), "errs.json")
+	if err := os.WriteFile(path, []byte(`{"separator": " >> "}`), 0o600); err != nil {
+		t.Fatal("Expected no error, got", err)
+	}
+
+	stop := ReloadOnSIGHUP(path)
+	defer stop()
+
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal("Expected no error, got", err)
+	}
+	if err := p.Signal(syscall.SIGHUP); err != nil {
+		t.Skip("SIGHUP is not supported:", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for CurrentConfig().Separator != " >> " {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the configuration file to be applied")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	stop()
+}
+
+func TestConfig_Concurrent(t *testing.T) {
+	restoreConfig(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				SetSupervisorErr(" ---> ")
+				SetLevel(slog.LevelError)
+				SetLogTypes(LogTypeText)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				Default().getLogger(Entry{Time: time.Now(), Level: slog.LevelDebug, Message: Unwrap(Wrap(New("cause"), "context"))})
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,122 @@
+package errs
+
+import (
+	"context"
+	"log/slog"
+)
+
+// attrsKey is the context key of the attributes attached with WithAttrs.
+type attrsKey struct{}
+
+// WithAttrs returns a copy of ctx carrying request-scoped attributes, such as a request ID,
+// a user ID or a tenant. Entries logged with LogCtx and errors wrapped with WrapCtx include
+// the attributes of the context as fields. Attributes of the parent context are kept;
+// an attribute with the same key replaces the parent one.
+//
+// Parameters:
+//   - ctx: The parent context.
+//   - args: Attributes as alternating keys and values or slog.Attr values, like slog.Logger.With.
+//
+// Returns:
+//   - A context carrying the attributes of ctx and args.
+func WithAttrs(ctx context.Context, args ...any) context.Context {
+	attrs := argsToAttrs(args)
+	if len(attrs) == 0 {
+		return ctx
+	}
+
+	parent := contextAttrs(ctx)
+	return context.WithValue(ctx, attrsKey{}, append(parent[:len(parent):len(parent)], attrs...))
+}
+
+// WrapCtx wraps an error like Wrap and stamps the attributes of ctx onto it, so that
+// they are logged with the error even if it is logged without the context.
+//
+// Parameters:
+//   - ctx: The context to take the attributes from, see WithAttrs.
+//   - err: The error to wrap. If this is nil, the function returns nil.
+//   - args: Variadic arguments representing the additional messages to append to the error message chain.
+//
+// Returns:
+//   - A new error with the combined messages, the original error context and the attributes.
+//     If the provided error is nil, the function returns nil.
+func WrapCtx(ctx context.Context, err error, args ...any) error {
+	if err == nil {
+		return nil
+	}
+
+	e := wrap(err, args...).(*errorString)
+	if attrs := contextAttrs(ctx); len(attrs) > 0 {
+		e.attrs = append(e.attrs[:len(e.attrs):len(e.attrs)], attrs...)
+	}
+	return e
+}
+
+// LogCtx logs an error like LogID, adding the attributes of ctx as fields of the entry.
+// The entry is delivered in the background, so it is written and sent to Telegram even once
+// ctx is done, such as the context of a finished HTTP request; only LogWith with Sync cancels
+// sending it with ctx.
+//
+// Parameters:
+//   - ctx: The context to take the attributes from, see WithAttrs.
+//   - err: The error to log. If this is nil, the function returns without doing anything.
+//   - req: The request object associated with the error. This can be of any type.
+//   - msgs: Variadic arguments representing additional messages to include in the log entry.
+//
+// Returns:
+//   - The ID of the entry. If the provided error is nil, it returns an empty string.
+func LogCtx(ctx context.Context, err error, req any, msgs ...any) string {
+	return defaultLogger.LogCtx(ctx, err, req, msgs...)
+}
+
+// contextAttrs returns the attributes attached to ctx with WithAttrs.
+func contextAttrs(ctx context.Context) []slog.Attr {
+	attrs, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	return attrs
+}
+
+// errorAttrs returns the attributes stamped onto an error with WrapCtx.
+func errorAttrs(err error) []slog.Attr {
+	e, ok := err.(*errorString)
+	if !ok || e == nil {
+		return nil
+	}
+
+	return e.attrs
+}
+
+// argsToAttrs converts alternating keys and values or slog.Attr values to attributes,
+// the same way slog does.
+func argsToAttrs(args []any) []slog.Attr {
+	return slog.Group("", args...).Value.Group()
+}
+
+// entryFields returns the fields of an entry for lists of attributes, such as those of an
+// error and a context. Later attributes replace earlier ones with the same key.
+func entryFields(attrs ...[]slog.Attr) map[string]any {
+	var fields map[string]any
+	for _, list := range attrs {
+		for _, a := range list {
+			if fields == nil {
+				fields = map[string]any{}
+			}
+			fields[a.Key] = attrValue(a.Value)
+		}
+	}
+	return fields
+}
+
+// attrValue returns the value of an attribute, converting groups to maps so that
+// every logger renders them as objects.
+func attrValue(v slog.Value) any {
+	v = v.Resolve()
+	if v.Kind() != slog.KindGroup {
+		return v.Any()
+	}
+
+	group := map[string]any{}
+	for _, a := range v.Group() {
+		group[a.Key] = attrValue(a.Value)
+	}
+	return group
+}
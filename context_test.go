@@ -0,0 +1,109 @@
+package errs
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithAttrs(t *testing.T) {
+	ctx := WithAttrs(context.Background(), "request_id", "r-1", slog.String("tenant", "acme"))
+	ctx = WithAttrs(ctx, "user_id", 42, "tenant", "globex")
+
+	fields := entryFields(contextAttrs(ctx))
+	want := map[string]any{"request_id": "r-1", "user_id": int64(42), "tenant": "globex"}
+	for key, value := range want {
+		if fields[key] != value {
+			t.Errorf("Expected %q to be %v, got %v", key, value, fields[key])
+		}
+	}
+
+	if WithAttrs(ctx) != ctx {
+		t.Error("Expected no new context without attributes")
+	}
+}
+
+func TestWrapCtx(t *testing.T) {
+	if WrapCtx(context.Background(), nil) != nil {
+		t.Fatal("Expected nil for a nil error")
+	}
+
+	ctx := WithAttrs(context.Background(), "request_id", "r-1")
+	err := Wrap(WrapCtx(ctx, New("cause"), "context"), "outer")
+
+	if Unwrap(err) != "outer ---> context ---> cause" || err.Error() != "cause" {
+		t.Fatalf("Expected WrapCtx to wrap like Wrap, got %q", Unwrap(err))
+	}
+	if fields := entryFields(errorAttrs(err)); fields["request_id"] != "r-1" {
+		t.Fatalf("Expected the attributes to survive wrapping, got %v", fields)
+	}
+	if fields := entryFields(errorAttrs(Join(" && ", New("other"), err))); fields["request_id"] != "r-1" {
+		t.Fatalf("Expected Join to keep the attributes, got %v", fields)
+	}
+}
+
+func TestAttrValue_Group(t *testing.T) {
+	got := attrValue(slog.GroupValue(slog.String("id", "u-1"), slog.Int("age", 30)))
+
+	group, ok := got.(map[string]any)
+	if !ok || group["id"] != "u-1" || group["age"] != int64(30) {
+		t.Fatalf("Expected the group as a map, got %v", got)
+	}
+}
+
+func TestLogCtx(t *testing.T) {
+	f := newFakeBotAPI(t)
+	setupFakeBot(t, f, BroadcastBotParams{ChatIDs: []int64{1}, ParseMode: ParseModeText, Lazy: true})
+
+	ctx := WithAttrs(context.Background(), "request_id", "r-1")
+	if id := LogCtx(ctx, WrapCtx(WithAttrs(ctx, "user_id", "u-1"), New("failed")), nil); id == "" {
+		t.Fatal("Expected an ID")
+	}
+
+	sent := f.waitCalls(t, "sendMessage", 1)
+	text := sent[0].Params.Get("text")
+	if !strings.Contains(text, `"request_id": "r-1"`) || !strings.Contains(text, `"user_id": "u-1"`) {
+		t.Fatalf("Expected the attributes in the message, got %q", text)
+	}
+}
+
+func TestLogCtx_Cancelled(t *testing.T) {
+	f := newFakeBotAPI(t)
+	setupFakeBot(t, f, BroadcastBotParams{ChatIDs: []int64{1}, Lazy: true})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Entries delivered in the background outlive their context, such as that of a finished request.
+	Default().getLogger(Entry{Time: time.Now(), Level: slog.LevelError, Fingerprint: "cancelled", ctx: ctx})
+	if len(f.calls("sendMessage")) != 1 {
+		t.Fatal("Expected the entry of a cancelled context to be sent in the background")
+	}
+
+	Default().getLogger(Entry{Time: time.Now(), Level: slog.LevelError, Fingerprint: "cancelled", ctx: ctx, sync: true})
+	if len(f.calls("sendMessage")) != 1 {
+		t.Fatal("Expected no message for a cancelled context with Sync")
+	}
+	for _, s := range GetStatus().Sinks {
+		if s.Name == BotSinkName && !strings.Contains(s.LastError, "context canceled") {
+			t.Fatalf("Expected the delivery to fail with the context, got %q", s.LastError)
+		}
+	}
+}
+
+func TestEntry_LogCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var buf bytes.Buffer
+	e := Entry{Time: time.Now(), Level: slog.LevelError, Message: "failed", ctx: ctx}
+	if err := e.log(newJSONLogger(&buf, slog.LevelError)); err != nil {
+		t.Fatal("Expected no error, got", err)
+	}
+	if !strings.Contains(buf.String(), "failed") {
+		t.Fatalf("Expected the logger to write the entry of a cancelled context, got %q", buf.String())
+	}
+}
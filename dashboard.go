@@ -0,0 +1,278 @@
+package errs
+
+import (
+	_ "embed"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dashboardPage is the page served by DebugHandler. It has no external assets.
+//
+//go:embed dashboard/index.html
+var dashboardPage []byte
+
+// defaultMuteDuration is the mute duration used when a mute request does not give one.
+const defaultMuteDuration = time.Hour
+
+// DebugHandler returns an http.Handler serving a debug dashboard of the recent errors:
+// the errors grouped by fingerprint with a sparkline of the last hour, the recent errors
+// with filters, the health of every sink, the queue depth and the current configuration.
+// Fingerprints can be muted and unmuted from the dashboard, like with the bot commands.
+//
+// The handler serves paths relative to where it is mounted, so strip the prefix:
+//
+//	http.Handle("/debug/errs/", http.StripPrefix("/debug/errs", errs.DebugHandler()))
+//
+// It also serves the data of the dashboard as JSON:
+//   - GET api/recent: recent errors, filtered by the since, until, level, code, q and limit parameters.
+//   - GET api/groups: recent errors grouped by fingerprint, with the same parameters.
+//   - GET api/status: queue depth, sink health and active mutes.
+//   - GET api/config: log types, level, separator and bots, with their tokens redacted.
+//   - POST api/mute: mutes the fingerprint parameter for the duration parameter, one hour by default.
+//   - POST api/unmute: unmutes the fingerprint parameter.
+//
+// The dashboard shows requests and can mute alerts, so only expose it to trusted users.
+func DebugHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", serveDashboardPage)
+	mux.HandleFunc("/api/recent", dashboardGet(serveDashboardRecent))
+	mux.HandleFunc("/api/groups", dashboardGet(serveDashboardGroups))
+	mux.HandleFunc("/api/status", dashboardGet(serveDashboardStatus))
+	mux.HandleFunc("/api/config", dashboardGet(serveDashboardConfig))
+	mux.HandleFunc("/api/mute", dashboardPost(serveDashboardMute))
+	mux.HandleFunc("/api/unmute", dashboardPost(serveDashboardUnmute))
+	return mux
+}
+
+// dashboardGroup is a group of recent errors as served by the dashboard.
+type dashboardGroup struct {
+	RecentGroup
+	Series     []int      // Errors per minute over the last hour, oldest first.
+	MutedUntil *time.Time `json:",omitempty"`
+}
+
+// dashboardStatus is the health of the logging pipeline as served by the dashboard.
+type dashboardStatus struct {
+	Status
+	Mutes map[string]time.Time
+}
+
+// dashboardConfig is the configuration of the default logger as served by the dashboard.
+type dashboardConfig struct {
+	Service   string
+	LogTypes  []LogType
+	LogFile   string `json:",omitempty"`
+	Level     slog.Level
+	Separator string
+	Bot       *dashboardBot `json:",omitempty"`
+}
+
+// dashboardBot is the configuration of a broadcast bot as served by the dashboard.
+type dashboardBot struct {
+	Service   string
+	Token     string // Only the bot ID; the secret part is redacted.
+	ChatIDs   []int64
+	Routes    int
+	ParseMode ParseMode
+	Commands  bool
+	Buttons   bool
+	Digest    string `json:",omitempty"`
+}
+
+// serveDashboardPage serves the dashboard page.
+func serveDashboardPage(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(dashboardPage)
+}
+
+// serveDashboardRecent serves the recent errors matching the query.
+func serveDashboardRecent(w http.ResponseWriter, r *http.Request) {
+	q, err := parseRecentQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	entries := Recent(q)
+	if entries == nil {
+		entries = []Entry{}
+	}
+	writeDashboardJSON(w, entries)
+}
+
+// serveDashboardGroups serves the recent errors matching the query, grouped by fingerprint.
+func serveDashboardGroups(w http.ResponseWriter, r *http.Request) {
+	q, err := parseRecentQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	mutes := Mutes()
+	groups := []dashboardGroup{}
+	for _, g := range RecentGroups(q) {
+		group := dashboardGroup{RecentGroup: g, Series: errorStats.series(g.Fingerprint, now)}
+		if until, ok := mutes[g.Fingerprint]; ok {
+			group.MutedUntil = &until
+		}
+		groups = append(groups, group)
+	}
+	writeDashboardJSON(w, groups)
+}
+
+// serveDashboardStatus serves the queue depth, the sink health and the active mutes.
+func serveDashboardStatus(w http.ResponseWriter, _ *http.Request) {
+	writeDashboardJSON(w, dashboardStatus{Status: GetStatus(), Mutes: Mutes()})
+}
+
+// serveDashboardConfig serves the configuration of the default logger.
+func serveDashboardConfig(w http.ResponseWriter, _ *http.Request) {
+	l := Default()
+	s := currentSettings()
+	config := dashboardConfig{Service: l.serviceName(), LogTypes: []LogType{}, Level: s.level, Separator: s.separator}
+	for _, sink := range l.sinks() {
+		config.LogTypes = append(config.LogTypes, sink.name)
+	}
+	if s.logFile != nil {
+		config.LogFile = s.logFile.Name()
+	}
+	if bot := l.Bot(); bot != nil {
+		config.Bot = bot.dashboardConfig()
+	}
+	writeDashboardJSON(w, config)
+}
+
+// serveDashboardMute mutes a fingerprint.
+func serveDashboardMute(w http.ResponseWriter, r *http.Request) {
+	fingerprint := r.FormValue("fingerprint")
+	if fingerprint == "" {
+		http.Error(w, "missing fingerprint", http.StatusBadRequest)
+		return
+	}
+
+	d := defaultMuteDuration
+	if value := r.FormValue("duration"); value != "" {
+		var err error
+		if d, err = time.ParseDuration(value); err != nil || d <= 0 {
+			http.Error(w, "invalid duration: "+value, http.StatusBadRequest)
+			return
+		}
+	}
+
+	Mute(fingerprint, d)
+	writeDashboardJSON(w, Mutes())
+}
+
+// serveDashboardUnmute unmutes a fingerprint.
+func serveDashboardUnmute(w http.ResponseWriter, r *http.Request) {
+	fingerprint := r.FormValue("fingerprint")
+	if fingerprint == "" {
+		http.Error(w, "missing fingerprint", http.StatusBadRequest)
+		return
+	}
+
+	Unmute(fingerprint)
+	writeDashboardJSON(w, Mutes())
+}
+
+// dashboardConfig returns the configuration of the bot, with its token redacted.
+func (bb *BroadcastBot) dashboardConfig() *dashboardBot {
+	config := &dashboardBot{
+		Service:   bb.service,
+		Token:     redactToken(bb.bot.Token),
+		ChatIDs:   bb.chatIDs,
+		Routes:    len(bb.routes().routes),
+		ParseMode: bb.parseMode,
+		Commands:  bb.commands,
+		Buttons:   bb.buttons,
+	}
+	if bb.digest != nil {
+		config.Digest = bb.digest.params.Window.String()
+	}
+	return config
+}
+
+// redactToken keeps the bot ID of a Bot API token and hides its secret part.
+func redactToken(token string) string {
+	if i := strings.IndexByte(token, ':'); i > 0 {
+		return token[:i] + ":<redacted>"
+	}
+	return "<redacted>"
+}
+
+// parseRecentQuery reads a RecentQuery from the parameters of a request.
+func parseRecentQuery(r *http.Request) (RecentQuery, error) {
+	values := r.URL.Query()
+	q := RecentQuery{Code: values.Get("code"), Text: values.Get("q")}
+
+	for _, bound := range []struct {
+		name string
+		t    *time.Time
+	}{{"since", &q.Since}, {"until", &q.Until}} {
+		if value := values.Get(bound.name); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return q, WrapF(err, "invalid %s", bound.name)
+			}
+			*bound.t = t
+		}
+	}
+
+	for _, value := range values["level"] {
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(value)); err != nil {
+			return q, Wrap(err, "invalid level")
+		}
+		q.Levels = append(q.Levels, level)
+	}
+
+	if value := values.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 0 {
+			return q, NewF("invalid limit: %s", value)
+		}
+		q.Limit = limit
+	}
+	return q, nil
+}
+
+// dashboardGet restricts a handler to GET requests.
+func dashboardGet(h http.HandlerFunc) http.HandlerFunc {
+	return dashboardMethod(http.MethodGet, h)
+}
+
+// dashboardPost restricts a handler to POST requests, so that links cannot change mutes.
+func dashboardPost(h http.HandlerFunc) http.HandlerFunc {
+	return dashboardMethod(http.MethodPost, h)
+}
+
+// dashboardMethod restricts a handler to a request method.
+func dashboardMethod(method string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// writeDashboardJSON writes v as the JSON response.
+func writeDashboardJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
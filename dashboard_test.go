@@ -0,0 +1,150 @@
+package errs
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newDashboard(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/debug/errs/", http.StripPrefix("/debug/errs", DebugHandler()))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func getDashboardJSON(t *testing.T, srv *httptest.Server, path string, v any) {
+	resp, err := http.Get(srv.URL + "/debug/errs/" + path)
+	if err != nil {
+		t.Fatal("Expected no error, got", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200 for %s, got %d", path, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatal("Expected valid JSON, got", err)
+	}
+}
+
+func TestDebugHandler_Page(t *testing.T) {
+	srv := newDashboard(t)
+
+	resp, err := http.Get(srv.URL + "/debug/errs/")
+	if err != nil {
+		t.Fatal("Expected no error, got", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		t.Fatalf("Expected the page, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	resp, err = http.Get(srv.URL + "/debug/errs/missing")
+	if err != nil {
+		t.Fatal("Expected no error, got", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("Expected 404 for an unknown path, got %d", resp.StatusCode)
+	}
+}
+
+func TestDebugHandler_RecentAndGroups(t *testing.T) {
+	resetStats(t)
+	srv := newDashboard(t)
+	now := time.Now()
+	Default().getLogger(Entry{Time: now, Level: slog.LevelError, Code: "dashboard_test", ErrorPath: "a", Fingerprint: "dash-a"})
+	Default().getLogger(Entry{Time: now, Level: slog.LevelError, Code: "dashboard_test", ErrorPath: "a", Fingerprint: "dash-a"})
+	Default().getLogger(Entry{Time: now, Level: slog.LevelWarn, Code: "dashboard_test", ErrorPath: "b", Fingerprint: "dash-b"})
+
+	var recent []Entry
+	getDashboardJSON(t, srv, "api/recent?code=dashboard_test&level=WARN", &recent)
+	if len(recent) != 1 || recent[0].Fingerprint != "dash-b" {
+		t.Fatalf("Expected the warning, got %+v", recent)
+	}
+
+	Mute("dash-a", time.Hour)
+	t.Cleanup(func() { Unmute("dash-a") })
+
+	var groups []dashboardGroup
+	getDashboardJSON(t, srv, "api/groups?code=dashboard_test", &groups)
+	if len(groups) != 2 || groups[0].Fingerprint != "dash-a" || groups[0].Count != 2 {
+		t.Fatalf("Expected 2 groups, largest first, got %+v", groups)
+	}
+	if len(groups[0].Series) != statsMinutes || groups[0].Series[statsMinutes-1] != 2 {
+		t.Fatalf("Expected the sparkline series, got %v", groups[0].Series)
+	}
+	if groups[0].MutedUntil == nil || groups[1].MutedUntil != nil {
+		t.Fatal("Expected the mute of the first group to be shown")
+	}
+
+	resp, err := http.Get(srv.URL + "/debug/errs/api/recent?since=yesterday")
+	if err != nil {
+		t.Fatal("Expected no error, got", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for an invalid query, got %d", resp.StatusCode)
+	}
+}
+
+func TestDebugHandler_Mute(t *testing.T) {
+	srv := newDashboard(t)
+	t.Cleanup(func() { Unmute("dash-mute") })
+
+	resp, err := http.Get(srv.URL + "/debug/errs/api/mute?fingerprint=dash-mute")
+	if err != nil {
+		t.Fatal("Expected no error, got", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed || IsMuted("dash-mute") {
+		t.Fatalf("Expected GET not to mute, got %d", resp.StatusCode)
+	}
+
+	resp, err = http.PostForm(srv.URL+"/debug/errs/api/mute", url.Values{"fingerprint": {"dash-mute"}, "duration": {"30m"}})
+	if err != nil {
+		t.Fatal("Expected no error, got", err)
+	}
+	resp.Body.Close()
+	if until, ok := Mutes()["dash-mute"]; !ok || time.Until(until) > 30*time.Minute {
+		t.Fatal("Expected the fingerprint to be muted for 30 minutes")
+	}
+
+	resp, err = http.PostForm(srv.URL+"/debug/errs/api/unmute", url.Values{"fingerprint": {"dash-mute"}})
+	if err != nil {
+		t.Fatal("Expected no error, got", err)
+	}
+	resp.Body.Close()
+	if IsMuted("dash-mute") {
+		t.Fatal("Expected the fingerprint to be unmuted")
+	}
+}
+
+func TestDebugHandler_StatusAndConfig(t *testing.T) {
+	f := newFakeBotAPI(t)
+	setupFakeBot(t, f, BroadcastBotParams{ChatIDs: []int64{1}, Lazy: true})
+	srv := newDashboard(t)
+
+	var status dashboardStatus
+	getDashboardJSON(t, srv, "api/status", &status)
+	if status.Mutes == nil {
+		t.Fatal("Expected the mutes in the status")
+	}
+
+	var config dashboardConfig
+	getDashboardJSON(t, srv, "api/config", &config)
+	if config.Separator != currentSettings().separator || len(config.LogTypes) == 0 {
+		t.Fatalf("Unexpected config %+v", config)
+	}
+	if config.Bot == nil || config.Bot.Token != "123:<redacted>" || len(config.Bot.ChatIDs) != 1 {
+		t.Fatalf("Expected the bot with a redacted token, got %+v", config.Bot)
+	}
+}
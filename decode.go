@@ -0,0 +1,350 @@
+package errs
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// decodeTree decodes a value parsed from JSON or YAML into v, reporting errors with the path
+// of the value, such as bot.chat_ids[1]. Objects are decoded over the fields already set in v,
+// so that missing keys keep their values, and unknown keys are rejected. Fields are named
+// after their json tags; embedded structs without a tag are inlined.
+//
+// Parameters:
+//   - path: The path of the value, used in errors.
+//   - v: The settable value to decode into.
+//   - data: The parsed value: nil, a bool, a number, a string, a []any or a map[string]any.
+//
+// Returns:
+//   - An error naming the path of the first value that does not fit its field.
+func decodeTree(path string, v reflect.Value, data any) error {
+	if v.Kind() == reflect.Pointer {
+		if data == nil {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return decodeTree(path, v.Elem(), data)
+	}
+
+	if text, ok := data.(string); ok {
+		if v.Type() == durationType {
+			d, err := time.ParseDuration(text)
+			if err != nil {
+				return NewF("%s: invalid duration %q", path, text)
+			}
+			v.SetInt(int64(d))
+			return nil
+		}
+		if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+			if err := v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(text)); err != nil {
+				return NewF("%s: invalid value %q", path, text)
+			}
+			return nil
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		if text, ok := data.(string); ok {
+			v.SetString(text)
+			return nil
+		}
+	case reflect.Bool:
+		if b, ok := data.(bool); ok {
+			v.SetBool(b)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := treeInt(data)
+		if v.Type() == durationType && (!ok || n != 0) {
+			return NewF("%s: expected a duration such as \"1m30s\", got %s", path, describeTree(data))
+		}
+		if ok && !v.OverflowInt(n) {
+			v.SetInt(n)
+			return nil
+		}
+		return NewF("%s: expected an integer, got %s", path, describeTree(data))
+	case reflect.Float32, reflect.Float64:
+		if f, ok := treeFloat(data); ok {
+			v.SetFloat(f)
+			return nil
+		}
+		return NewF("%s: expected a number, got %s", path, describeTree(data))
+	case reflect.Slice:
+		return decodeTreeSlice(path, v, data)
+	case reflect.Map:
+		return decodeTreeMap(path, v, data)
+	case reflect.Struct:
+		return decodeTreeStruct(path, v, data)
+	}
+	return NewF("%s: expected %s, got %s", path, describeKind(v.Type()), describeTree(data))
+}
+
+// decodeTreeSlice decodes a list into a new slice.
+func decodeTreeSlice(path string, v reflect.Value, data any) error {
+	if data == nil {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	items, ok := data.([]any)
+	if !ok {
+		return NewF("%s: expected a list, got %s", path, describeTree(data))
+	}
+
+	slice := reflect.MakeSlice(v.Type(), len(items), len(items))
+	for i, item := range items {
+		if err := decodeTree(fmt.Sprintf("%s[%d]", path, i), slice.Index(i), item); err != nil {
+			return err
+		}
+	}
+	v.Set(slice)
+	return nil
+}
+
+// decodeTreeMap decodes an object into a new map with string keys.
+func decodeTreeMap(path string, v reflect.Value, data any) error {
+	if data == nil {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	object, ok := data.(map[string]any)
+	if !ok || v.Type().Key().Kind() != reflect.String {
+		return NewF("%s: expected an object, got %s", path, describeTree(data))
+	}
+
+	m := reflect.MakeMapWithSize(v.Type(), len(object))
+	for key, value := range object {
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if err := decodeTree(joinPath(path, key), elem, value); err != nil {
+			return err
+		}
+		m.SetMapIndex(reflect.ValueOf(key).Convert(v.Type().Key()), elem)
+	}
+	v.Set(m)
+	return nil
+}
+
+// decodeTreeStruct decodes an object over the fields of a struct.
+func decodeTreeStruct(path string, v reflect.Value, data any) error {
+	object, ok := data.(map[string]any)
+	if !ok {
+		return NewF("%s: expected an object, got %s", path, describeTree(data))
+	}
+
+	fields := structFields(v.Type())
+	for key, value := range object {
+		index, ok := fields[key]
+		if !ok {
+			return NewF("%s: unknown field", joinPath(path, key))
+		}
+		if err := decodeTree(joinPath(path, key), v.FieldByIndex(index), value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeText decodes a value given as text, such as an environment variable or a flag.
+// Lists of strings, numbers and levels are separated by commas; other lists, objects and
+// maps are given as JSON. Empty text clears lists and maps.
+//
+// Parameters:
+//   - path: The path of the value, used in errors.
+//   - v: The settable value to decode into.
+//   - text: The text to decode.
+//
+// Returns:
+//   - An error naming the path of the first value that does not fit its field.
+func decodeText(path string, v reflect.Value, text string) error {
+	t := v.Type()
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t.Kind() == reflect.String, t == durationType, reflect.PointerTo(t).Implements(textUnmarshalerType):
+		return decodeTree(path, v, text)
+	case t.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return NewF("%s: expected a boolean, got %q", path, text)
+		}
+		return decodeTree(path, v, b)
+	case t.Kind() == reflect.Slice && isScalar(t.Elem()):
+		if text = strings.TrimSpace(text); text == "" {
+			return decodeTree(path, v, nil)
+		}
+		var items []any
+		for _, item := range strings.Split(text, ",") {
+			items = append(items, textScalar(t.Elem(), strings.TrimSpace(item)))
+		}
+		return decodeTree(path, v, items)
+	case t.Kind() == reflect.Slice, t.Kind() == reflect.Map, t.Kind() == reflect.Struct:
+		if strings.TrimSpace(text) == "" {
+			return decodeTree(path, v, nil)
+		}
+		var data any
+		if err := unmarshalTree([]byte(text), &data); err != nil {
+			return WrapF(err, "%s: invalid JSON", path)
+		}
+		return decodeTree(path, v, data)
+	default:
+		return decodeTree(path, v, textScalar(t, text))
+	}
+}
+
+// unmarshalTree parses JSON into a tree for decodeTree, keeping numbers exact.
+func unmarshalTree(data []byte, tree *any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(tree); err != nil {
+		return err
+	}
+	if dec.More() {
+		return New("unexpected data after the value")
+	}
+	return nil
+}
+
+// textScalar converts the text of a number to a json.Number, so that decodeTree checks it
+// like a number from a file. Other text is kept as a string.
+func textScalar(t reflect.Type, text string) any {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Float32, reflect.Float64:
+		if t != durationType && !reflect.PointerTo(t).Implements(textUnmarshalerType) {
+			return json.Number(text)
+		}
+	}
+	return text
+}
+
+// isScalar reports whether values of the type are written as a single word in text.
+func isScalar(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// structFields returns the index of every field of a struct by the name in its json tag.
+// Embedded structs without a tag are inlined and fields tagged "-" are left out.
+func structFields(t reflect.Type) map[string][]int {
+	fields := map[string][]int{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		switch {
+		case name == "-":
+		case f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct:
+			// Like encoding/json, the fields of embedded structs are inlined even if the
+			// struct type is unexported.
+			for key, index := range structFields(f.Type) {
+				fields[key] = append([]int{i}, index...)
+			}
+		case !f.IsExported():
+		case name == "":
+			fields[f.Name] = []int{i}
+		default:
+			fields[name] = []int{i}
+		}
+	}
+	return fields
+}
+
+// treeInt returns the integer value of a parsed number.
+func treeInt(data any) (int64, bool) {
+	switch n := data.(type) {
+	case json.Number:
+		if i, err := n.Int64(); err == nil {
+			return i, true
+		}
+		// Integral numbers written with a fraction or an exponent, such as 3.0 or 1e3.
+		f, err := n.Float64()
+		if err != nil {
+			return 0, false
+		}
+		return treeInt(f)
+	case int:
+		return int64(n), true
+	case int64:
+		return n, true
+	case uint64:
+		return int64(n), n <= math.MaxInt64
+	case float64:
+		return int64(n), n == math.Trunc(n) && math.Abs(n) < 1<<63
+	}
+	return 0, false
+}
+
+// treeFloat returns the value of a parsed number.
+func treeFloat(data any) (float64, bool) {
+	switch n := data.(type) {
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case float64:
+		return n, true
+	}
+	i, ok := treeInt(data)
+	return float64(i), ok
+}
+
+// describeTree describes a parsed value in an error.
+func describeTree(data any) string {
+	switch data := data.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "a boolean"
+	case string:
+		return strconv.Quote(data)
+	case []any:
+		return "a list"
+	case map[string]any:
+		return "an object"
+	}
+	if _, ok := treeFloat(data); ok {
+		return fmt.Sprint(data)
+	}
+	if n, ok := data.(json.Number); ok {
+		// Text given for a number, such as an environment variable, that is not one.
+		return strconv.Quote(string(n))
+	}
+	return fmt.Sprintf("%T", data)
+}
+
+// describeKind describes the values expected for a type in an error.
+func describeKind(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	}
+	return t.String()
+}
+
+// joinPath appends a key to a path.
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
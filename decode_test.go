@@ -0,0 +1,315 @@
+package errs
+
+import (
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecodeTree(t *testing.T) {
+	var tree any
+	if err := unmarshalTree([]byte(`{
+		"separator": " | ",
+		"level": "warn",
+		"rotation": {"max_size_mb": 10, "max_age": "24h"},
+		"bot": {"chat_ids": [1, -1002], "edit_repeats": "5m"},
+		"routes": [{"levels": ["ERROR"], "chats": [{"chat_id": 3, "thread_id": 4}]}],
+		"throttles": {"TELEGRAM": {"window": "1m", "rate": 0.5}}
+	}`), &tree); err != nil {
+		t.Fatal("Expected no error, got", err)
+	}
+
+	s := Setup{Config: Config{LogTypes: []LogType{LogTypeText}}}
+	if err := decodeTree("", reflect.ValueOf(&s).Elem(), tree); err != nil {
+		t.Fatal("Expected no error, got", err)
+	}
+
+	if s.Separator != " | " || s.Level != slog.LevelWarn || !reflect.DeepEqual(s.LogTypes, []LogType{LogTypeText}) {
+		t.Fatalf("Expected the fields to be decoded over the existing ones, got %+v", s.Config)
+	}
+	if s.Rotation != (RotationParams{MaxSizeMB: 10, MaxAge: 24 * time.Hour}) {
+		t.Fatalf("Unexpected rotation %+v", s.Rotation)
+	}
+	if s.Bot == nil || !reflect.DeepEqual(s.Bot.ChatIDs, []int64{1, -1002}) || s.Bot.EditRepeats != 5*time.Minute {
+		t.Fatalf("Unexpected bot %+v", s.Bot)
+	}
+	if len(s.Routes) != 1 || s.Routes[0].Levels[0] != slog.LevelError || s.Routes[0].Chats[0] != (ChatTarget{ChatID: 3, ThreadID: 4}) {
+		t.Fatalf("Unexpected routes %+v", s.Routes)
+	}
+	if s.Throttles["TELEGRAM"] != (ThrottleParams{Window: time.Minute, Rate: 0.5}) {
+		t.Fatalf("Unexpected throttles %+v", s.Throttles)
+	}
+}
+
+func TestDecodeTree_Errors(t *testing.T) {
+	tests := []struct {
+		json string
+		want string
+	}{
+		{`{"bot": {"chat_ids": [1, "x"]}}`, `bot.chat_ids[1]: expected an integer, got "x"`},
+		{`{"rotation": {"max_size": 1}}`, `rotation.max_size: unknown field`},
+		{`{"bot": {"edit_repeats": "soon"}}`, `bot.edit_repeats: invalid duration "soon"`},
+		{`{"bot": {"edit_repeats": 60}}`, `bot.edit_repeats: expected a duration`},
+		{`{"level": "loud"}`, `level: invalid value "loud"`},
+		{`{"routes": [{"chats": {"chat_id": 1}}]}`, `routes[0].chats: expected a list, got an object`},
+		{`{"separator": 1}`, `separator: expected a string, got 1`},
+		{`{"bot": {"chat_ids": [1e30]}}`, `bot.chat_ids[0]: expected an integer`},
+	}
+
+	for _, tt := range tests {
+		var tree any
+		if err := unmarshalTree([]byte(tt.json), &tree); err != nil {
+			t.Fatal("Expected no error, got", err)
+		}
+		var s Setup
+		err := decodeTree("", reflect.ValueOf(&s).Elem(), tree)
+		if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+			t.Errorf("%s: expected %q, got %v", tt.json, tt.want, err)
+		}
+	}
+}
+
+func TestDecodeText(t *testing.T) {
+	var s Setup
+	v := reflect.ValueOf(&s).Elem()
+	fields := map[string]*configField{}
+	for _, f := range configFields() {
+		fields[f.path] = f
+	}
+
+	for path, text := range map[string]string{
+		"bot.chat_ids":         "1, -2",
+		"bot.buttons":          "true",
+		"level":                "DEBUG",
+		"rotation.max_age":     "1h",
+		"rotation.max_size_mb": "5",
+		"log_types":            "JSON,FILE",
+		"throttles":            `{"FILE": {"limit": 2}}`,
+	} {
+		if err := decodeText(path, fields[path].value(v), text); err != nil {
+			t.Fatalf("%s: expected no error, got %v", path, err)
+		}
+	}
+
+	if s.Bot == nil || !reflect.DeepEqual(s.Bot.ChatIDs, []int64{1, -2}) || !s.Bot.Buttons {
+		t.Fatalf("Unexpected bot %+v", s.Bot)
+	}
+	if s.Level != slog.LevelDebug || s.Rotation.MaxAge != time.Hour || s.Rotation.MaxSizeMB != 5 {
+		t.Fatalf("Unexpected setup %+v", s)
+	}
+	if !reflect.DeepEqual(s.LogTypes, []LogType{LogTypeJSON, LogTypeFile}) || s.Throttles["FILE"].Limit != 2 {
+		t.Fatalf("Unexpected setup %+v", s)
+	}
+
+	if err := decodeText("ERRS_BOT_CHAT_IDS", fields["bot.chat_ids"].value(v), "1,two"); err == nil || !strings.HasPrefix(err.Error(), "ERRS_BOT_CHAT_IDS[1]") {
+		t.Fatalf("Expected the invalid item to be reported, got %v", err)
+	}
+	if err := decodeText("ERRS_BOT_BUTTONS", fields["bot.buttons"].value(v), "sometimes"); err == nil {
+		t.Fatal("Expected the invalid boolean to be reported")
+	}
+}
+
+func TestConfigFields(t *testing.T) {
+	names := map[string]string{}
+	for _, f := range configFields() {
+		names[f.env()] = f.flag()
+	}
+
+	for env, flag := range map[string]string{
+		"ERRS_LOG_TYPES":            "errs-log-types",
+		"ERRS_BOT_TOKEN_FILE":       "errs-bot-token-file",
+		"ERRS_BOT_DIGEST_WINDOW":    "errs-bot-digest-window",
+		"ERRS_ROTATION_MAX_SIZE_MB": "errs-rotation-max-size-mb",
+		"ERRS_ROUTES":               "errs-routes",
+		"ERRS_THROTTLES":            "errs-throttles",
+	} {
+		if names[env] != flag {
+			t.Errorf("Expected %s with the flag %s, got %q", env, flag, names[env])
+		}
+	}
+}
+
+// decodeTarget has a field of every kind decodeTree supports.
+type decodeTarget struct {
+	decodeInline
+
+	String   string            `json:"string"`
+	Bool     bool              `json:"bool"`
+	Int8     int8              `json:"int8"`
+	Int      int               `json:"int"`
+	Float    float64           `json:"float"`
+	Duration time.Duration     `json:"duration"`
+	Level    slog.Level        `json:"level"`
+	List     []int             `json:"list"`
+	Map      map[string]int    `json:"map"`
+	IntKeys  map[int]string    `json:"int_keys"`
+	Pointer  *decodeInline     `json:"pointer"`
+	Uint     uint              `json:"uint"`
+	Skipped  string            `json:"-"`
+	Untagged string            // Named after the field, since it has no tag.
+	Nested   map[string][]bool `json:"nested"`
+
+	unexported string
+}
+
+type decodeInline struct {
+	Inline string `json:"inline"`
+}
+
+func TestDecodeTree_Kinds(t *testing.T) {
+	tests := []struct {
+		name  string
+		json  string
+		check func(d decodeTarget) bool
+	}{
+		{"string", `{"string": "s"}`, func(d decodeTarget) bool { return d.String == "s" }},
+		{"bool", `{"bool": true}`, func(d decodeTarget) bool { return d.Bool }},
+		{"int8", `{"int8": -128}`, func(d decodeTarget) bool { return d.Int8 == -128 }},
+		{"integral float", `{"int": 3.0}`, func(d decodeTarget) bool { return d.Int == 3 }},
+		{"float", `{"float": 1.5}`, func(d decodeTarget) bool { return d.Float == 1.5 }},
+		{"float from integer", `{"float": 2}`, func(d decodeTarget) bool { return d.Float == 2 }},
+		{"duration", `{"duration": "1m30s"}`, func(d decodeTarget) bool { return d.Duration == 90*time.Second }},
+		{"zero duration", `{"duration": 0}`, func(d decodeTarget) bool { return d.Duration == 0 }},
+		{"text unmarshaler", `{"level": "WARN+2"}`, func(d decodeTarget) bool { return d.Level == slog.LevelWarn+2 }},
+		{"list", `{"list": [1, 2]}`, func(d decodeTarget) bool { return reflect.DeepEqual(d.List, []int{1, 2}) }},
+		{"null list", `{"list": null}`, func(d decodeTarget) bool { return d.List == nil }},
+		{"map", `{"map": {"a": 1}}`, func(d decodeTarget) bool { return d.Map["a"] == 1 }},
+		{"null map", `{"map": null}`, func(d decodeTarget) bool { return d.Map == nil }},
+		{"nested", `{"nested": {"a": [true]}}`, func(d decodeTarget) bool { return d.Nested["a"][0] }},
+		{"pointer", `{"pointer": {"inline": "p"}}`, func(d decodeTarget) bool { return d.Pointer != nil && d.Pointer.Inline == "p" }},
+		{"null pointer", `{"pointer": null}`, func(d decodeTarget) bool { return d.Pointer == nil }},
+		{"inlined embedded struct", `{"inline": "i"}`, func(d decodeTarget) bool { return d.Inline == "i" }},
+		{"untagged field", `{"Untagged": "u"}`, func(d decodeTarget) bool { return d.Untagged == "u" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tree any
+			if err := unmarshalTree([]byte(tt.json), &tree); err != nil {
+				t.Fatal("Expected no error, got", err)
+			}
+			d := decodeTarget{List: []int{9}, Map: map[string]int{"x": 1}, Pointer: &decodeInline{}}
+			if err := decodeTree("", reflect.ValueOf(&d).Elem(), tree); err != nil {
+				t.Fatal("Expected no error, got", err)
+			}
+			if !tt.check(d) {
+				t.Fatalf("Unexpected result %+v", d)
+			}
+		})
+	}
+}
+
+func TestDecodeTree_KindErrors(t *testing.T) {
+	tests := []struct {
+		json string
+		want string
+	}{
+		{`{"string": true}`, `string: expected a string, got a boolean`},
+		{`{"bool": "yes"}`, `bool: expected a boolean, got "yes"`},
+		{`{"int8": 300}`, `int8: expected an integer, got 300`},
+		{`{"int": 1.5}`, `int: expected an integer, got 1.5`},
+		{`{"int": [1]}`, `int: expected an integer, got a list`},
+		{`{"float": "x"}`, `float: expected a number, got "x"`},
+		{`{"duration": 5}`, `duration: expected a duration such as "1m30s", got 5`},
+		{`{"duration": true}`, `duration: expected a duration such as "1m30s", got a boolean`},
+		{`{"list": {"a": 1}}`, `list: expected a list, got an object`},
+		{`{"list": [1, null]}`, `list[1]: expected an integer, got null`},
+		{`{"map": [1]}`, `map: expected an object, got a list`},
+		{`{"map": {"a": "b"}}`, `map.a: expected an integer, got "b"`},
+		{`{"int_keys": {"1": "a"}}`, `int_keys: expected an object, got an object`},
+		{`{"pointer": "p"}`, `pointer: expected an object, got "p"`},
+		{`{"pointer": {"other": 1}}`, `pointer.other: unknown field`},
+		{`{"uint": 1}`, `uint: expected uint, got 1`},
+		{`{"Skipped": "s"}`, `Skipped: unknown field`},
+		{`{"unexported": "s"}`, `unexported: unknown field`},
+		{`[1]`, `: expected an object, got a list`},
+	}
+
+	for _, tt := range tests {
+		var tree any
+		if err := unmarshalTree([]byte(tt.json), &tree); err != nil {
+			t.Fatal("Expected no error, got", err)
+		}
+		var d decodeTarget
+		err := decodeTree("", reflect.ValueOf(&d).Elem(), tree)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("%s: expected %q, got %v", tt.json, tt.want, err)
+		}
+	}
+}
+
+func TestDecodeTree_YAMLNumbers(t *testing.T) {
+	tests := []struct {
+		data any
+		want int64
+		ok   bool
+	}{
+		{7, 7, true},
+		{int64(-7), -7, true},
+		{uint64(7), 7, true},
+		{uint64(1 << 63), 0, false},
+		{float64(7), 7, true},
+		{7.5, 0, false},
+		{"7", 0, false},
+	}
+
+	for _, tt := range tests {
+		var n int64
+		err := decodeTree("n", reflect.ValueOf(&n).Elem(), tt.data)
+		if (err == nil) != tt.ok || tt.ok && n != tt.want {
+			t.Errorf("%#v: expected %d and ok %v, got %d and %v", tt.data, tt.want, tt.ok, n, err)
+		}
+	}
+}
+
+func TestDecodeText_Kinds(t *testing.T) {
+	var d decodeTarget
+	v := reflect.ValueOf(&d).Elem()
+
+	for field, text := range map[string]string{
+		"String":   "plain text, with a comma",
+		"Float":    "2.5",
+		"Duration": "2s",
+		"Level":    "ERROR",
+		"List":     " 1 , 2 ",
+		"Map":      `{"a": 1}`,
+		"Pointer":  `{"inline": "p"}`,
+	} {
+		if err := decodeText(field, v.FieldByName(field), text); err != nil {
+			t.Fatalf("%s: expected no error, got %v", field, err)
+		}
+	}
+	if d.String != "plain text, with a comma" || d.Float != 2.5 || d.Duration != 2*time.Second || d.Level != slog.LevelError {
+		t.Fatalf("Unexpected scalars %+v", d)
+	}
+	if !reflect.DeepEqual(d.List, []int{1, 2}) || d.Map["a"] != 1 || d.Pointer == nil || d.Pointer.Inline != "p" {
+		t.Fatalf("Unexpected lists and objects %+v", d)
+	}
+
+	for field, text := range map[string]string{"List": " ", "Map": "", "Pointer": ""} {
+		if err := decodeText(field, v.FieldByName(field), text); err != nil {
+			t.Fatalf("%s: expected no error, got %v", field, err)
+		}
+	}
+	if d.List != nil || d.Map != nil || d.Pointer != nil {
+		t.Fatalf("Expected empty text to clear lists, maps and pointers, got %+v", d)
+	}
+
+	errors := map[string]string{
+		"Int":      `Int: expected an integer, got "many"`,
+		"Bool":     `Bool: expected a boolean, got "many"`,
+		"Duration": `Duration: invalid duration "many"`,
+		"Map":      `Map: invalid JSON`,
+	}
+	for field, want := range errors {
+		err := decodeText(field, v.FieldByName(field), "many")
+		if err == nil || !strings.HasPrefix(Unwrap(err), want) {
+			t.Errorf("%s: expected %q, got %v", field, want, err)
+		}
+	}
+	if err := unmarshalTree([]byte(`{} {}`), new(any)); err == nil {
+		t.Error("Expected data after the value to be rejected")
+	}
+}
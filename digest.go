@@ -0,0 +1,185 @@
+package errs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DigestParams configures the digest mode of the broadcast bot.
+// Instead of one message per error, the bot collects the errors of a window,
+// groups them by fingerprint and sends one summary message per window.
+type DigestParams struct {
+	// Window is how long errors are collected before the digest is sent. Zero disables the digest.
+	Window time.Duration `json:"window"`
+	// SendFirst sends the first occurrence of a fingerprint immediately,
+	// unless it was already seen in the current or the previous window.
+	SendFirst bool `json:"send_first"`
+}
+
+// digest collects entries grouped by fingerprint until the window is flushed.
+type digest struct {
+	params DigestParams
+
+	mu     sync.Mutex
+	start  time.Time
+	groups map[string]*digestGroup
+	order  []string        // Fingerprints in order of first occurrence.
+	prev   map[string]bool // Fingerprints seen in the previous window.
+}
+
+// digestGroup summarizes the entries of a window that share a fingerprint.
+type digestGroup struct {
+	Count     int       `json:"count"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	Message   string    `json:"msg"`
+	ErrorPath string    `json:"Error Path"`
+	Request   any       `json:"request"`
+
+	sample Entry
+}
+
+// newDigest creates a digest starting its first window now.
+func newDigest(params DigestParams) *digest {
+	return &digest{
+		params: params,
+		start:  time.Now(),
+		groups: map[string]*digestGroup{},
+		prev:   map[string]bool{},
+	}
+}
+
+// add collects an entry for the current window.
+// It reports whether the entry should also be sent immediately.
+func (d *digest) add(e Entry) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	g, ok := d.groups[e.Fingerprint]
+	if ok {
+		g.Count++
+		g.LastSeen = e.Time
+		return false
+	}
+
+	d.groups[e.Fingerprint] = &digestGroup{
+		Count:     1,
+		FirstSeen: e.Time,
+		LastSeen:  e.Time,
+		Message:   e.Message,
+		ErrorPath: e.ErrorPath,
+		Request:   e.Request,
+		sample:    e,
+	}
+	d.order = append(d.order, e.Fingerprint)
+
+	return d.params.SendFirst && !d.prev[e.Fingerprint]
+}
+
+// flush closes the current window and returns its groups in order of first occurrence,
+// together with the start of the window.
+func (d *digest) flush(now time.Time) ([]*digestGroup, time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	groups := make([]*digestGroup, 0, len(d.order))
+	prev := make(map[string]bool, len(d.order))
+	for _, fp := range d.order {
+		groups = append(groups, d.groups[fp])
+		prev[fp] = true
+	}
+
+	start := d.start
+	d.start = now
+	d.groups = map[string]*digestGroup{}
+	d.order = nil
+	d.prev = prev
+
+	return groups, start
+}
+
+// runDigest sends a digest at the end of every window until the bot is closed.
+func (bb *BroadcastBot) runDigest() {
+	ticker := time.NewTicker(bb.digest.params.Window)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			reportBotError(bb.sendDigest(now))
+		case <-bb.ctx.Done():
+			reportBotError(bb.sendDigest(time.Now()))
+			return
+		}
+	}
+}
+
+// sendDigest flushes the digest and sends every chat one message with the groups routed to it.
+func (bb *BroadcastBot) sendDigest(now time.Time) error {
+	groups, start := bb.digest.flush(now)
+	if len(groups) == 0 {
+		return nil
+	}
+
+	var chats []ChatTarget
+	byChat := map[ChatTarget][]*digestGroup{}
+	for _, g := range groups {
+		for _, chat := range bb.route(g.sample) {
+			if _, ok := byChat[chat]; !ok {
+				chats = append(chats, chat)
+			}
+			byChat[chat] = append(byChat[chat], g)
+		}
+	}
+
+	var errs error
+	for _, chat := range chats {
+		chatGroups := byChat[chat]
+
+		total := 0
+		for _, g := range chatGroups {
+			total += g.Count
+		}
+
+		body, err := marshalDigest(chatGroups)
+		if err != nil {
+			errs = Join(" && ", errs, Wrap(err, "failed to marshal digest"))
+			continue
+		}
+
+		header := fmt.Sprintf(
+			"Service Name: %s\nDigest: %d errors in %d groups\nFrom: %s\nTo: %s\n",
+			bb.serviceName(chatGroups[0].sample),
+			total,
+			len(chatGroups),
+			start.Format(time.RFC3339),
+			now.Format(time.RFC3339),
+		)
+
+		if err := bb.sendLog(context.Background(), []ChatTarget{chat}, logMessage{header: header, body: bb.formatJSON(string(body))}); err != nil {
+			errs = Join(" && ", errs, err)
+		}
+	}
+	return errs
+}
+
+// marshalDigest converts the groups of a digest to JSON. Requests that cannot be converted,
+// such as channels or NaN, are formatted with fmt, so that they do not lose the digest.
+func marshalDigest(groups []*digestGroup) ([]byte, error) {
+	items := make([]json.RawMessage, 0, len(groups))
+	for _, g := range groups {
+		data, err := json.Marshal(g)
+		if err != nil {
+			safe := *g
+			safe.Request = fmt.Sprint(g.Request)
+			if data, err = json.Marshal(safe); err != nil {
+				return nil, err
+			}
+		}
+		items = append(items, data)
+	}
+	return json.Marshal(items)
+}
@@ -0,0 +1,97 @@
+package errs
+
+import (
+	"encoding/json"
+	"log/slog"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDigest_Add(t *testing.T) {
+	d := newDigest(DigestParams{Window: time.Minute, SendFirst: true})
+	first := Entry{Time: time.Now(), Fingerprint: "a"}
+
+	if !d.add(first) {
+		t.Fatal("Expected the first occurrence to be sent immediately")
+	}
+	if d.add(first) {
+		t.Fatal("Expected a repeated occurrence to be collected")
+	}
+
+	groups, _ := d.flush(time.Now())
+	if len(groups) != 1 || groups[0].Count != 2 {
+		t.Fatalf("Expected one group of 2 entries, got %v", groups)
+	}
+
+	if d.add(first) {
+		t.Fatal("Expected a fingerprint seen in the previous window not to be sent immediately")
+	}
+	d.flush(time.Now())
+	d.flush(time.Now())
+
+	if !d.add(first) {
+		t.Fatal("Expected a fingerprint not seen for a whole window to be sent immediately")
+	}
+}
+
+func TestBroadcastBot_Digest(t *testing.T) {
+	f := newFakeBotAPI(t)
+	setupFakeBot(t, f, BroadcastBotParams{ChatIDs: []int64{1}, Digest: DigestParams{Window: time.Hour}, Lazy: true})
+
+	for i := 0; i < 3; i++ {
+		Default().getLogger(Entry{Time: time.Now(), Level: slog.LevelError, ErrorPath: "timeout", Fingerprint: "a"})
+	}
+	Default().getLogger(Entry{Time: time.Now(), Level: slog.LevelError, ErrorPath: "not found", Fingerprint: "b"})
+
+	if len(f.calls("sendMessage")) != 0 {
+		t.Fatal("Expected entries to be collected until the window ends")
+	}
+
+	if err := Default().Bot().sendDigest(time.Now()); err != nil {
+		t.Fatal("Expected no error, got", err)
+	}
+
+	sent := f.calls("sendMessage")
+	if len(sent) != 1 {
+		t.Fatalf("Expected one digest message, got %d", len(sent))
+	}
+
+	text := sent[0].Params.Get("text")
+	if !strings.HasPrefix(text, "Service Name: test\\_service\n") {
+		t.Fatalf("Expected the digest to name the service of the bot, got %q", text)
+	}
+	if !strings.Contains(text, "4 errors in 2 groups") {
+		t.Fatalf("Expected the digest to count the errors, got %q", text)
+	}
+
+	body := text[strings.Index(text, "```json\n")+len("```json\n") : strings.LastIndex(text, "\n```")]
+	var groups []map[string]any
+	if err := json.Unmarshal([]byte(body), &groups); err != nil {
+		t.Fatal("Expected the digest body to be JSON, got", err)
+	}
+	if len(groups) != 2 || groups[0]["count"] != 3.0 || groups[0]["Error Path"] != "timeout" {
+		t.Fatalf("Unexpected digest groups %v", groups)
+	}
+}
+
+func TestBroadcastBot_DigestUnmarshalableRequest(t *testing.T) {
+	f := newFakeBotAPI(t)
+	setupFakeBot(t, f, BroadcastBotParams{ChatIDs: []int64{1}, Digest: DigestParams{Window: time.Hour}, Lazy: true})
+
+	Default().getLogger(Entry{Time: time.Now(), Level: slog.LevelError, ErrorPath: "timeout", Fingerprint: "a", Request: make(chan int)})
+	Default().getLogger(Entry{Time: time.Now(), Level: slog.LevelError, ErrorPath: "not found", Fingerprint: "b", Request: math.NaN()})
+
+	if err := Default().Bot().sendDigest(time.Now()); err != nil {
+		t.Fatal("Expected no error, got", err)
+	}
+
+	sent := f.calls("sendMessage")
+	if len(sent) != 1 || !strings.Contains(sent[0].Params.Get("text"), "2 errors in 2 groups") {
+		t.Fatalf("Expected the digest despite unmarshalable requests, got %v", sent)
+	}
+	if text := sent[0].Params.Get("text"); !strings.Contains(text, "NaN") {
+		t.Fatalf("Expected the request to be formatted with fmt, got %q", text)
+	}
+}
@@ -0,0 +1,105 @@
+package errs
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+// Entry is a single logged error as it is delivered to the loggers and the broadcast bot.
+// It is also the data notification templates are executed with, see BroadcastBotParams.Template.
+type Entry struct {
+	ID          string         // Unique ID of the entry, see LogID.
+	Time        time.Time      // Time the error was logged.
+	Level       slog.Level     // Severity of the entry.
+	Service     string         // Name of the service that logged the error.
+	Message     string         // Context messages passed to Log, joined with the separator.
+	ErrorPath   string         // Message chain of the error, see Unwrap.
+	RootCause   string         // Message of the original error, see Error.
+	Code        string         // Error code, see Code.
+	Request     any            // Request object passed to Log.
+	Fields      map[string]any // Additional fields of the entry.
+	Fingerprint string         // Identifies entries of the same error, see Fingerprint.
+	Metadata    Metadata       // Process that logged the error, see SetMetadata.
+
+	ctx     context.Context // Context the entry is delivered with, see LogCtx. Nil uses context.Background.
+	noAlert bool            // Not sent to the broadcast bots, see SetPolicy and NoNotify.
+	sync    bool            // Delivered on the goroutine of the caller, see Sync.
+	only    []string        // Sinks the entry is delivered to. Nil delivers it to all sinks, see Only.
+}
+
+// attrs returns the attributes of the entry in the order they are logged.
+// The ID, the code, the fingerprint and the metadata are only included when they are set.
+func (e Entry) attrs() []slog.Attr {
+	var attrs []slog.Attr
+	if e.ID != "" {
+		attrs = append(attrs, slog.String("error_id", e.ID))
+	}
+	attrs = append(attrs, slog.String("Error Path", e.ErrorPath))
+	if e.Code != "" {
+		attrs = append(attrs, slog.String("code", e.Code))
+	}
+	if e.Fingerprint != "" {
+		attrs = append(attrs, slog.String("fingerprint", e.Fingerprint))
+	}
+
+	keys := make([]string, 0, len(e.Fields))
+	for key := range e.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		attrs = append(attrs, slog.Any(key, e.Fields[key]))
+	}
+
+	attrs = append(attrs, slog.Any("request", e.Request))
+	if !e.Metadata.isZero() {
+		attrs = append(attrs, slog.Any("metadata", e.Metadata))
+	}
+	return attrs
+}
+
+// context returns the context the entry is delivered with.
+func (e Entry) context() context.Context {
+	if e.ctx != nil {
+		return e.ctx
+	}
+	return context.Background()
+}
+
+// sendContext returns the context the bots send the entry with. An entry delivered with Sync
+// is sent with its own context, so that the caller can cancel sending it. Entries delivered
+// in the background are sent even once their context is done, such as the context of
+// a finished HTTP request, but for botSendTimeout at most.
+func (e Entry) sendContext() (context.Context, context.CancelFunc) {
+	if e.sync {
+		return context.WithCancel(e.context())
+	}
+	return context.WithTimeout(context.WithoutCancel(e.context()), botSendTimeout)
+}
+
+// log writes the entry to the logger, returning the error of its handler.
+// Local loggers always write the entry: the context of the entry only passes its values
+// to the handler, since it is often done before the asynchronous delivery, such as the
+// context of a finished HTTP request.
+func (e Entry) log(logger *slog.Logger) error {
+	ctx := context.WithoutCancel(e.context())
+	if !logger.Enabled(ctx, e.Level) {
+		return nil
+	}
+
+	r := slog.NewRecord(e.Time, e.Level, e.Message, 0)
+	r.AddAttrs(e.attrs()...)
+	return logger.Handler().Handle(ctx, r)
+}
+
+// json renders the entry the same way the JSON logger does.
+func (e Entry) json() string {
+	var buf bytes.Buffer
+	r := slog.NewRecord(e.Time, e.Level, e.Message, 0)
+	r.AddAttrs(e.attrs()...)
+	_ = slog.NewJSONHandler(&buf, nil).Handle(context.Background(), r)
+	return buf.String()
+}
@@ -0,0 +1,53 @@
+package errs
+
+import (
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestEntry_JSON(t *testing.T) {
+	e := Entry{
+		Time:      time.Now(),
+		Level:     slog.LevelError,
+		Message:   "message",
+		ErrorPath: "context ---> cause",
+		Code:      "code",
+		Request:   map[string]int{"id": 1},
+		Fields:    map[string]any{"tenant": "acme"},
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal([]byte(e.json()), &got); err != nil {
+		t.Fatal("Expected valid JSON, got", err)
+	}
+
+	want := map[string]any{
+		"level":      "ERROR",
+		"msg":        "message",
+		"Error Path": "context ---> cause",
+		"code":       "code",
+		"tenant":     "acme",
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("Expected %q to be %v, got %v", key, value, got[key])
+		}
+	}
+
+	if _, ok := got["request"].(map[string]any); !ok {
+		t.Errorf("Expected the request to be logged, got %v", got["request"])
+	}
+}
+
+func TestEntry_JSON_NoCode(t *testing.T) {
+	var got map[string]any
+	if err := json.Unmarshal([]byte(Entry{}.json()), &got); err != nil {
+		t.Fatal("Expected valid JSON, got", err)
+	}
+
+	if _, ok := got["code"]; ok {
+		t.Error("Expected no code field for an error without code")
+	}
+}
@@ -3,12 +3,17 @@ package errs
 import (
 	"errors"
 	"fmt"
+	"log/slog"
 )
 
 // errorString - improved error structure that stores a message and the original error.
 type errorString struct {
-	message string // Detailed error message.
-	origErr string // Original error.
+	message string      // Detailed error message.
+	origErr string      // Original error.
+	code    string      // Error code, see WithCode.
+	stack   []uintptr   // Stack the error was created at, see Fingerprint.
+	attrs   []slog.Attr // Attributes stamped onto the error, see WrapCtx.
+	causes  []error     // Errors wrapped by the error, see Is.
 }
 
 // New returns a new error that includes a message and the original error.
@@ -27,6 +32,7 @@ func New(message string) error {
 	return &errorString{
 		message: message,
 		origErr: message,
+		stack:   callers(1),
 	}
 }
 
@@ -38,7 +44,12 @@ func New(message string) error {
 //
 // The function returns an error that contains the formatted message.
 func NewF(format string, a ...any) error {
-	return New(fmt.Sprintf(format, a...))
+	message := fmt.Sprintf(format, a...)
+	return &errorString{
+		message: message,
+		origErr: message,
+		stack:   callers(1),
+	}
 }
 
 // Error implements the error interface, returning the original error message.
@@ -73,8 +84,19 @@ func (e *errorString) unwrap() string {
 	return e.message
 }
 
+// Unwrap returns the errors wrapped by the error with Wrap, WithCode or Join,
+// so that errors.Is and errors.As find sentinel errors through them.
+func (e *errorString) Unwrap() []error {
+	if e == nil {
+		return nil
+	}
+
+	return e.causes
+}
+
 // Is checks if the target error is equal to the given error.
-// It returns true if they are the same or if the target error matches the original error.
+// It returns true if they are the same or if the target error matches the original error
+// or any error it wraps, such as context.Canceled wrapped with Wrap.
 //
 // Parameters:
 // - err: The error to be checked.
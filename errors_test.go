@@ -1,6 +1,7 @@
 package errs
 
 import (
+	"errors"
 	"testing"
 )
 
@@ -116,3 +117,21 @@ func TestIsNil_NonNilError(t *testing.T) {
 		t.Fatal("Expected IsNil(err) to return false for a non-nil error")
 	}
 }
+
+func TestIs_WrappedSentinel(t *testing.T) {
+	sentinel := errors.New("sentinel")
+	wrapped := []error{
+		Wrap(sentinel, "wrapped"),
+		WithCode(Wrap(sentinel, "wrapped"), "code"),
+		Join(" && ", New("other"), sentinel),
+		Wrap(Join(" && ", Wrap(sentinel, "inner")), "outer"),
+	}
+	for i, err := range wrapped {
+		if !errors.Is(err, sentinel) || !Is(err, sentinel) {
+			t.Fatalf("Expected error %d to match the sentinel: %s", i, Unwrap(err))
+		}
+	}
+	if errors.Is(New("sentinel"), sentinel) {
+		t.Fatal("Expected an unrelated error with the same message not to match")
+	}
+}
@@ -0,0 +1,172 @@
+package errs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+const (
+	// stackDepth is the number of stack frames captured when an error is created.
+	stackDepth = 32
+	// fingerprintFrames is the number of top stack frames included in a fingerprint.
+	fingerprintFrames = 3
+)
+
+// Patterns masked by NormalizeMessage, in the order they are applied.
+var (
+	quotedPattern = regexp.MustCompile(`"[^"]*"|'[^']*'|` + "`[^`]*`")
+	uuidPattern   = regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`)
+	hexPattern    = regexp.MustCompile(`(?i)\b0x[0-9a-f]+\b|\b[0-9a-f]*[0-9][0-9a-f]*[a-f][0-9a-f]*\b|\b[0-9a-f]*[a-f][0-9a-f]*[0-9][0-9a-f]*\b`)
+	numberPattern = regexp.MustCompile(`\d+(\.\d+)?`)
+)
+
+// fingerprints holds the hooks that override how fingerprints are computed.
+var fingerprints struct {
+	sync.RWMutex
+	normalize func(string) string
+	custom    func(error) string
+}
+
+// Fingerprint returns a short, stable hash identifying errors of the same kind, so that
+// they can be grouped in digests, statistics and mutes. It is computed from the normalized
+// message chain of the error (see NormalizeMessage), its code and, for errors created by
+// this package, the top frames of the stack the error was created at.
+// The fingerprint is logged as the "fingerprint" field of every entry.
+//
+// Parameters:
+//   - err: The error to fingerprint.
+//
+// Returns:
+//   - A 16 character hex string, or the result of the function set with SetFingerprintFunc.
+//     If the error is nil, it returns an empty string.
+func Fingerprint(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	fingerprints.RLock()
+	normalize, custom := fingerprints.normalize, fingerprints.custom
+	fingerprints.RUnlock()
+
+	if custom != nil {
+		return custom(err)
+	}
+	if normalize == nil {
+		normalize = NormalizeMessage
+	}
+
+	h := sha256.New()
+	h.Write([]byte(Code(err)))
+	h.Write([]byte{0})
+	h.Write([]byte(normalize(Unwrap(err))))
+	for _, frame := range stackFrames(err, fingerprintFrames) {
+		h.Write([]byte{0})
+		h.Write([]byte(frame))
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+// NormalizeMessage masks the parts of an error message that vary between occurrences of
+// the same error: quoted values become "<q>", UUIDs "<uuid>", hex values "<hex>" and
+// numbers "<n>". It is the default normalization used by Fingerprint.
+//
+// Example:
+//
+//	NormalizeMessage(`user 42 not found: "bob"`) // Output: user <n> not found: <q>
+func NormalizeMessage(msg string) string {
+	msg = quotedPattern.ReplaceAllString(msg, "<q>")
+	msg = uuidPattern.ReplaceAllString(msg, "<uuid>")
+	msg = hexPattern.ReplaceAllString(msg, "<hex>")
+	return numberPattern.ReplaceAllString(msg, "<n>")
+}
+
+// SetFingerprintNormalizer replaces the normalization applied to the message chain of an
+// error before it is fingerprinted. A custom normalizer can call NormalizeMessage to
+// extend the default one. Nil restores NormalizeMessage.
+func SetFingerprintNormalizer(normalize func(msg string) string) {
+	fingerprints.Lock()
+	defer fingerprints.Unlock()
+
+	fingerprints.normalize = normalize
+}
+
+// SetFingerprintFunc replaces Fingerprint entirely, for example to group errors by their
+// code only. Nil restores the default fingerprint.
+func SetFingerprintFunc(fingerprint func(err error) string) {
+	fingerprints.Lock()
+	defer fingerprints.Unlock()
+
+	fingerprints.custom = fingerprint
+}
+
+// callers returns the stack of the caller of the function calling callers,
+// skipping skip more frames.
+func callers(skip int) []uintptr {
+	var pcs [stackDepth]uintptr
+	n := runtime.Callers(skip+2, pcs[:])
+	return pcs[:n:n]
+}
+
+// Stack returns the stack an error of this package was created at with New, NewF, Wrap or
+// WithCode, innermost frame first. It returns nil for other errors.
+func Stack(err error) []runtime.Frame {
+	stack := stackOf(err)
+	if len(stack) == 0 {
+		return nil
+	}
+
+	var frames []runtime.Frame
+	iter := runtime.CallersFrames(stack)
+	for {
+		frame, more := iter.Next()
+		frames = append(frames, frame)
+		if !more {
+			return frames
+		}
+	}
+}
+
+// stackOf returns the stack an error of this package was created at, or nil.
+func stackOf(err error) []uintptr {
+	e, ok := err.(*errorString)
+	if !ok || e == nil {
+		return nil
+	}
+
+	return e.stack
+}
+
+// stackOrCallers returns the stack an error of this package was created at. For other errors
+// it returns the stack of the caller of the function calling stackOrCallers, skipping skip more frames.
+func stackOrCallers(err error, skip int) []uintptr {
+	if stack := stackOf(err); stack != nil {
+		return stack
+	}
+	return callers(skip + 1)
+}
+
+// stackFrames returns the function names of the top n frames of the stack an error
+// was created at. Line numbers are left out so that fingerprints survive unrelated edits.
+func stackFrames(err error, n int) []string {
+	stack := stackOf(err)
+	if len(stack) == 0 {
+		return nil
+	}
+
+	var names []string
+	frames := runtime.CallersFrames(stack)
+	for len(names) < n {
+		frame, more := frames.Next()
+		if frame.Function != "" && !strings.HasPrefix(frame.Function, "runtime.") {
+			names = append(names, frame.Function)
+		}
+		if !more {
+			break
+		}
+	}
+	return names
+}
@@ -0,0 +1,113 @@
+package errs
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeMessage(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want string
+	}{
+		{`user 42 not found`, `user <n> not found`},
+		{`order "A-17" rejected: 'out of stock'`, `order <q> rejected: <q>`},
+		{`session 3f2b6c1e-8a4d-4c2e-9b1a-7d6e5f4c3b2a expired`, `session <uuid> expired`},
+		{`bad pointer 0xc000123abc, hash deadbeef42`, `bad pointer <hex>, hash <hex>`},
+		{`took 1.25s ---> context canceled`, `took <n>s ---> context canceled`},
+		{`cafe closed`, `cafe closed`},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeMessage(tt.msg); got != tt.want {
+			t.Errorf("NormalizeMessage(%q) = %q, want %q", tt.msg, got, tt.want)
+		}
+	}
+}
+
+// newUserError creates errors at the same place, as repeated occurrences of one error are.
+func newUserError(id int) error {
+	return Wrap(NewF("user %d not found", id), "failed to load profile")
+}
+
+func TestFingerprint(t *testing.T) {
+	if Fingerprint(nil) != "" {
+		t.Fatal("Expected no fingerprint for a nil error")
+	}
+
+	fp := Fingerprint(newUserError(1))
+	if len(fp) != 16 {
+		t.Fatalf("Expected a 16 character fingerprint, got %q", fp)
+	}
+	if Fingerprint(newUserError(2)) != fp {
+		t.Fatal("Expected errors differing only in numbers to share a fingerprint")
+	}
+
+	if Fingerprint(WithCode(newUserError(1), "not_found")) == fp {
+		t.Fatal("Expected the code to change the fingerprint")
+	}
+	if Fingerprint(Wrap(NewF("user %d not found", 1), "failed to load profile")) == fp {
+		t.Fatal("Expected an error created elsewhere to have another fingerprint")
+	}
+}
+
+func TestFingerprint_ForeignError(t *testing.T) {
+	a := Fingerprint(errors.New("dial tcp 10.0.0.1:5432: connection refused"))
+	b := Fingerprint(errors.New("dial tcp 10.0.0.2:5432: connection refused"))
+	if a == "" || a != b {
+		t.Fatalf("Expected errors of other packages to be fingerprinted by message, got %q and %q", a, b)
+	}
+}
+
+func TestFingerprint_Stack(t *testing.T) {
+	err := newUserError(1)
+
+	frames := stackFrames(err, fingerprintFrames)
+	if len(frames) == 0 || !strings.HasSuffix(frames[0], ".newUserError") {
+		t.Fatalf("Expected the stack to start where the error was created, got %v", frames)
+	}
+
+	if got := stackFrames(Join(" && ", errors.New("other"), err), 1); len(got) != 1 || got[0] != frames[0] {
+		t.Fatalf("Expected Join to keep the stack of the first error with one, got %v", got)
+	}
+	if got := stackFrames(UnwrapE(err), 1); len(got) != 1 || got[0] != frames[0] {
+		t.Fatalf("Expected UnwrapE to keep the stack, got %v", got)
+	}
+}
+
+func TestSetFingerprintNormalizer(t *testing.T) {
+	t.Cleanup(func() { SetFingerprintNormalizer(nil) })
+
+	fp := Fingerprint(newUserError(1))
+	SetFingerprintNormalizer(func(msg string) string { return msg })
+	if Fingerprint(newUserError(1)) == Fingerprint(newUserError(2)) {
+		t.Fatal("Expected the normalizer to be used")
+	}
+
+	SetFingerprintNormalizer(nil)
+	if Fingerprint(newUserError(1)) != fp {
+		t.Fatal("Expected nil to restore the default normalizer")
+	}
+}
+
+func TestSetFingerprintFunc(t *testing.T) {
+	t.Cleanup(func() { SetFingerprintFunc(nil) })
+
+	SetFingerprintFunc(Code)
+	if got := Fingerprint(WithCode(New("failed"), "payment")); got != "payment" {
+		t.Fatalf("Expected the custom fingerprint, got %q", got)
+	}
+}
+
+func TestEntry_JSON_Fingerprint(t *testing.T) {
+	var got map[string]any
+	if err := json.Unmarshal([]byte(Entry{Fingerprint: "0123456789abcdef"}.json()), &got); err != nil {
+		t.Fatal("Expected valid JSON, got", err)
+	}
+
+	if got["fingerprint"] != "0123456789abcdef" {
+		t.Fatalf("Expected the fingerprint to be logged, got %v", got["fingerprint"])
+	}
+}
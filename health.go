@@ -0,0 +1,96 @@
+package errs
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Status describes the state of the logging pipeline.
+type Status struct {
+	QueueDepth int          // Entries passed to Log that are not delivered to every sink yet.
+	Sinks      []SinkStatus // Delivery statistics of every sink, sorted by name.
+}
+
+// SinkStatus describes the deliveries of a single sink, such as a logger or the broadcast bot.
+type SinkStatus struct {
+	Name        string
+	Delivered   int
+	Failed      int
+	Suppressed  int // Entries dropped by the throttle of the sink, see SetThrottle.
+	Retried     int // Entries the sink rejected and that were sent again.
+	LastError   string
+	LastErrorAt time.Time
+}
+
+// pending counts the entries passed to Log that are still being delivered.
+var pending atomic.Int64
+
+// sinkStatuses holds the delivery statistics of every sink that was used.
+var sinkStatuses = struct {
+	sync.Mutex
+	byName map[string]*SinkStatus
+}{byName: map[string]*SinkStatus{}}
+
+// GetStatus returns the queue depth and the delivery statistics of every sink.
+func GetStatus() Status {
+	sinkStatuses.Lock()
+	defer sinkStatuses.Unlock()
+
+	status := Status{QueueDepth: int(pending.Load())}
+	for _, s := range sinkStatuses.byName {
+		status.Sinks = append(status.Sinks, *s)
+	}
+	sort.Slice(status.Sinks, func(i, j int) bool { return status.Sinks[i].Name < status.Sinks[j].Name })
+
+	return status
+}
+
+// recordSuppressed counts an entry the throttle of a sink did not deliver.
+func recordSuppressed(name string) {
+	sinkStatuses.Lock()
+	sinkStatus(name).Suppressed++
+	sinkStatuses.Unlock()
+
+	observeDelivery(name, DeliveryDropped)
+}
+
+// recordRetry counts an entry a sink rejected and that is sent again.
+func recordRetry(name string) {
+	sinkStatuses.Lock()
+	sinkStatus(name).Retried++
+	sinkStatuses.Unlock()
+
+	observeDelivery(name, DeliveryRetried)
+}
+
+// sinkStatus returns the statistics of a sink, creating them on first use.
+// The caller must hold sinkStatuses.
+func sinkStatus(name string) *SinkStatus {
+	s, ok := sinkStatuses.byName[name]
+	if !ok {
+		s = &SinkStatus{Name: name}
+		sinkStatuses.byName[name] = s
+	}
+	return s
+}
+
+// recordDelivery updates the statistics of a sink with the outcome of a delivery.
+func recordDelivery(name string, err error) {
+	outcome := DeliverySuccess
+
+	sinkStatuses.Lock()
+	s := sinkStatus(name)
+	if err != nil {
+		outcome = DeliveryFailure
+		s.Failed++
+		s.LastError = err.Error()
+		s.LastErrorAt = time.Now()
+	} else {
+		s.Delivered++
+	}
+	sinkStatuses.Unlock()
+
+	observeDelivery(name, outcome)
+}
@@ -0,0 +1,23 @@
+package errs
+
+import (
+	"testing"
+)
+
+func TestGetStatus(t *testing.T) {
+	recordDelivery("TEST_SINK", nil)
+	recordDelivery("TEST_SINK", New("disk full"))
+
+	for _, s := range GetStatus().Sinks {
+		if s.Name != "TEST_SINK" {
+			continue
+		}
+
+		if s.Delivered < 1 || s.Failed < 1 || s.LastError != "disk full" || s.LastErrorAt.IsZero() {
+			t.Fatalf("Unexpected sink status %+v", s)
+		}
+		return
+	}
+
+	t.Fatal("Expected the sink to be listed")
+}
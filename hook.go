@@ -0,0 +1,145 @@
+package errs
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+)
+
+// HookResult tells Log what to do with an entry after a BeforeLogHook.
+type HookResult string
+
+const (
+	HookKeep HookResult = "keep" // Deliver the entry, with the changes of the hook.
+	HookDrop HookResult = "drop" // Drop the entry: later hooks and observers are skipped and it is neither counted nor delivered.
+)
+
+// BeforeLogHook receives the entry of every error logged with Log, LogID, LogCtx or LogWith
+// before it is observed and delivered, and may change it, for example to add fields or to
+// rewrite the message. Hooks run on the goroutine delivering the entry and must not block.
+type BeforeLogHook func(ctx context.Context, err error, e *Entry) HookResult
+
+// EntryFilter reports whether an entry is delivered to a sink, see AddFilter.
+type EntryFilter func(e Entry) bool
+
+// sinkFilter is a filter registered for a sink. An empty sink applies to all sinks.
+type sinkFilter struct {
+	sink string
+	fn   EntryFilter
+}
+
+// AddBeforeLog registers a hook that can enrich, rewrite or drop logged entries. Hooks run
+// in the order they are registered, each receiving the entry as changed by the previous ones.
+// A hook that panics is skipped: its changes are discarded, the panic is written to the
+// loggers and the entry continues with the next hook.
+//
+// Parameters:
+//   - fn: The hook to register.
+//
+// Returns:
+//   - A function that unregisters the hook.
+func AddBeforeLog(fn BeforeLogHook) (remove func()) {
+	hooks.Lock()
+	defer hooks.Unlock()
+
+	p := &fn
+	hooks.beforeLog = append(hooks.beforeLog, p)
+	return func() {
+		hooks.Lock()
+		defer hooks.Unlock()
+
+		hooks.beforeLog = removeHook(hooks.beforeLog, p)
+	}
+}
+
+// AddFilter registers a filter deciding which entries are delivered to a sink. Unlike a
+// BeforeLogHook dropping an entry, a filtered entry is still observed, counted and kept in
+// memory. An entry is delivered to a sink only if every filter of the sink accepts it.
+// A filter that panics accepts the entry, and the panic is written to the loggers.
+//
+// Parameters:
+//   - sink: The sink to filter: a LogType such as "FILE" or BotSinkName, case-insensitive.
+//     An empty sink filters the entries of all sinks.
+//   - fn: The filter to register.
+//
+// Returns:
+//   - A function that unregisters the filter.
+func AddFilter(sink string, fn EntryFilter) (remove func()) {
+	hooks.Lock()
+	defer hooks.Unlock()
+
+	p := &sinkFilter{sink: sink, fn: fn}
+	hooks.filters = append(hooks.filters, p)
+	return func() {
+		hooks.Lock()
+		defer hooks.Unlock()
+
+		hooks.filters = removeHook(hooks.filters, p)
+	}
+}
+
+// SetField sets a field of the entry, creating its fields if it has none.
+// It is meant for BeforeLogHook functions adding data to entries.
+//
+// Parameters:
+//   - key: The key of the field.
+//   - value: The value of the field.
+func (e *Entry) SetField(key string, value any) {
+	if e.Fields == nil {
+		e.Fields = map[string]any{}
+	}
+	e.Fields[key] = value
+}
+
+// beforeLog runs the registered hooks on an entry.
+// It reports false if a hook drops the entry.
+func beforeLog(ctx context.Context, err error, e *Entry) bool {
+	hooks.RLock()
+	beforeLog := hooks.beforeLog
+	hooks.RUnlock()
+
+	for _, fn := range beforeLog {
+		// The hook changes a copy, so that the changes of a hook that panics are discarded.
+		changed := *e
+		changed.Fields = maps.Clone(e.Fields)
+		result, ok := runHook(func() HookResult { return (*fn)(ctx, err, &changed) })
+		if !ok {
+			continue
+		}
+		if result == HookDrop {
+			return false
+		}
+		*e = changed
+	}
+	return true
+}
+
+// accepts reports whether the registered filters deliver an entry to a sink.
+func accepts(sink string, e Entry) bool {
+	hooks.RLock()
+	filters := hooks.filters
+	hooks.RUnlock()
+
+	for _, f := range filters {
+		if f.sink != "" && !strings.EqualFold(f.sink, sink) {
+			continue
+		}
+		if accepted, ok := runHook(func() bool { return f.fn(e) }); ok && !accepted {
+			return false
+		}
+	}
+	return true
+}
+
+// runHook calls fn, recovering from a panic in it. It reports false if fn panicked,
+// after writing the panic to the loggers.
+func runHook[T any](fn func() T) (result T, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			logInternal("Log hook panicked", "panic", fmt.Sprint(r))
+		}
+	}()
+
+	return fn(), true
+}
@@ -0,0 +1,126 @@
+package errs
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAddBeforeLog(t *testing.T) {
+	observed := make(chan Entry, 1)
+	removeObserver := AddLogObserver(func(_ context.Context, err error, e Entry) {
+		if err != nil {
+			observed <- e
+		}
+	})
+	defer removeObserver()
+
+	removeFirst := AddBeforeLog(func(_ context.Context, _ error, e *Entry) HookResult {
+		e.Message = "first: " + e.Message
+		e.SetField("flag", true)
+		return HookKeep
+	})
+	defer removeFirst()
+	removeSecond := AddBeforeLog(func(_ context.Context, err error, e *Entry) HookResult {
+		if Code(err) == "noisy" {
+			return HookDrop
+		}
+		e.Message = "second: " + e.Message
+		e.Level = slog.LevelWarn
+		return HookKeep
+	})
+	defer removeSecond()
+
+	LogWith(New("failed"), Msg("message"), Sync())
+	e := <-observed
+	if e.Message != "second: first: message" || e.Level != slog.LevelWarn || e.Fields["flag"] != true {
+		t.Fatalf("Expected the hooks to change the entry in order, got %+v", e)
+	}
+
+	LogWith(WithCode(New("failed"), "noisy"), Sync())
+	select {
+	case e := <-observed:
+		t.Fatalf("Expected the entry to be dropped, got %+v", e)
+	default:
+	}
+
+	removeSecond()
+	LogWith(WithCode(New("failed"), "noisy"), Msg("message"), Sync())
+	if e := <-observed; e.Message != "first: message" {
+		t.Fatalf("Expected the removed hook to be skipped, got %q", e.Message)
+	}
+}
+
+func TestAddBeforeLog_Panic(t *testing.T) {
+	observed := make(chan Entry, 1)
+	removeObserver := AddLogObserver(func(_ context.Context, err error, e Entry) {
+		if err != nil {
+			observed <- e
+		}
+	})
+	defer removeObserver()
+
+	removePanic := AddBeforeLog(func(_ context.Context, _ error, e *Entry) HookResult {
+		e.Message = "changed"
+		e.SetField("partial", true)
+		panic("hook failed")
+	})
+	defer removePanic()
+	removeNext := AddBeforeLog(func(_ context.Context, _ error, e *Entry) HookResult {
+		e.SetField("next", true)
+		return HookKeep
+	})
+	defer removeNext()
+
+	LogWith(New("failed"), Msg("message"), Fields("tenant", "acme"), Sync())
+	e := <-observed
+	if e.Message != "message" || e.Fields["partial"] != nil {
+		t.Fatalf("Expected the changes of the panicking hook to be discarded, got %+v", e)
+	}
+	if e.Fields["tenant"] != "acme" || e.Fields["next"] != true {
+		t.Fatalf("Expected the entry to continue with the next hook, got %v", e.Fields)
+	}
+}
+
+func TestAddFilter(t *testing.T) {
+	restoreConfig(t)
+	path := filepath.Join(t.TempDir(), "errors.json")
+	c := CurrentConfig()
+	c.LogTypes, c.LogFile = []LogType{LogTypeFile}, path
+	if err := ApplyConfig(c); err != nil {
+		t.Fatal(err)
+	}
+
+	f := newFakeBotAPI(t)
+	setupFakeBot(t, f, BroadcastBotParams{ChatIDs: []int64{1}})
+
+	removeBot := AddFilter(strings.ToLower(BotSinkName), func(e Entry) bool { return e.Code != "noisy" })
+	defer removeBot()
+	removeAll := AddFilter("", func(e Entry) bool { return !strings.Contains(e.ErrorPath, "ignored") })
+	defer removeAll()
+	removePanic := AddFilter(string(LogTypeFile), func(Entry) bool { panic("filter failed") })
+	defer removePanic()
+
+	LogWith(WithCode(New("noisy failure"), "noisy"), Sync())
+	LogWith(New("ignored failure"), Sync())
+	LogWith(New("real failure"), Sync())
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "noisy failure") || strings.Contains(string(data), "ignored failure") {
+		t.Fatalf("Expected the file to get the entries its filters accept, got %s", data)
+	}
+	if !strings.Contains(string(data), "Log hook panicked") {
+		t.Fatalf("Expected the panic of the filter to be logged, got %s", data)
+	}
+
+	sent := f.calls("sendMessage")
+	if len(sent) != 1 || !strings.Contains(sent[0].Params.Get("text"), "real failure") {
+		t.Fatalf("Expected only the real failure to be sent, got %v", sent)
+	}
+}
@@ -0,0 +1,72 @@
+package errs
+
+import (
+	"crypto/rand"
+	"sync"
+	"time"
+)
+
+// idAlphabet is Crockford's base32 alphabet used to encode error IDs.
+const idAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ids generates error IDs. IDs generated in the same millisecond increment the random part
+// of the previous ID, so that IDs sort in the order they were generated.
+var ids struct {
+	sync.Mutex
+	ms      uint64
+	entropy [10]byte
+}
+
+// newID returns a new ULID-style error ID for the given time: 26 characters of Crockford's
+// base32 encoding a 48-bit millisecond timestamp followed by 80 random bits. IDs sort
+// lexically by time, so they can be used to look up an entry in the logs and chats.
+func newID(t time.Time) string {
+	ids.Lock()
+	defer ids.Unlock()
+
+	ms := uint64(t.UnixMilli())
+	if ms <= ids.ms {
+		// Keep IDs of the same millisecond (or of a clock moving backwards) monotonic.
+		ms = ids.ms
+		incrementEntropy(&ids.entropy)
+	} else if _, err := rand.Read(ids.entropy[:]); err != nil {
+		incrementEntropy(&ids.entropy)
+	}
+	ids.ms = ms
+
+	var b [16]byte
+	for i := 0; i < 6; i++ {
+		b[i] = byte(ms >> (40 - 8*i))
+	}
+	copy(b[6:], ids.entropy[:])
+	return encodeID(b)
+}
+
+// incrementEntropy adds one to the random part of an ID.
+func incrementEntropy(entropy *[10]byte) {
+	for i := len(entropy) - 1; i >= 0; i-- {
+		entropy[i]++
+		if entropy[i] != 0 {
+			return
+		}
+	}
+}
+
+// encodeID encodes the 128 bits of an ID as 26 base32 characters, most significant first.
+func encodeID(b [16]byte) string {
+	out := make([]byte, 26)
+	// 26 characters hold 130 bits; the two leading bits are always zero.
+	var acc uint32
+	bits := 2
+	pos := 0
+	for _, v := range b {
+		acc = acc<<8 | uint32(v)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			out[pos] = idAlphabet[(acc>>bits)&31]
+			pos++
+		}
+	}
+	return string(out)
+}
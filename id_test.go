@@ -0,0 +1,79 @@
+package errs
+
+import (
+	"encoding/json"
+	"log/slog"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewID(t *testing.T) {
+	now := time.Now()
+
+	got := make([]string, 1000)
+	seen := make(map[string]bool, len(got))
+	for i := range got {
+		got[i] = newID(now)
+		if len(got[i]) != 26 || strings.Trim(got[i], idAlphabet) != "" {
+			t.Fatalf("Expected a 26 character base32 ID, got %q", got[i])
+		}
+		if seen[got[i]] {
+			t.Fatalf("Expected unique IDs, got %q twice", got[i])
+		}
+		seen[got[i]] = true
+	}
+
+	if !sort.StringsAreSorted(got) {
+		t.Fatal("Expected IDs of the same millisecond to sort in the order they were generated")
+	}
+	if later := newID(now.Add(time.Hour)); later < got[len(got)-1] {
+		t.Fatalf("Expected a later ID to sort after the earlier ones, got %q < %q", later, got[len(got)-1])
+	}
+}
+
+func TestEncodeID(t *testing.T) {
+	var b [16]byte
+	if got := encodeID(b); got != strings.Repeat("0", 26) {
+		t.Fatalf("Expected zeros, got %q", got)
+	}
+
+	for i := range b {
+		b[i] = 0xff
+	}
+	if got := encodeID(b); got != "7"+strings.Repeat("Z", 25) {
+		t.Fatalf("Expected the largest ID, got %q", got)
+	}
+}
+
+func TestLogID(t *testing.T) {
+	if id := LogID(nil, nil); id != "" {
+		t.Fatalf("Expected no ID for a nil error, got %q", id)
+	}
+
+	f := newFakeBotAPI(t)
+	setupFakeBot(t, f, BroadcastBotParams{ChatIDs: []int64{1}, ParseMode: ParseModeText, Lazy: true})
+
+	id := LogID(New("failed"), nil)
+	if len(id) != 26 {
+		t.Fatalf("Expected an ID, got %q", id)
+	}
+
+	sent := f.waitCalls(t, "sendMessage", 1)
+	if text := sent[0].Params.Get("text"); !strings.Contains(text, "Error ID: "+id+"\n") {
+		t.Fatalf("Expected the message to show the ID, got %q", text)
+	}
+}
+
+func TestEntry_JSON_ID(t *testing.T) {
+	var got map[string]any
+	e := Entry{ID: "01HF0000000000000000000000", Time: time.Now(), Level: slog.LevelError}
+	if err := json.Unmarshal([]byte(e.json()), &got); err != nil {
+		t.Fatal("Expected valid JSON, got", err)
+	}
+
+	if got["error_id"] != e.ID {
+		t.Fatalf("Expected the ID to be logged, got %v", got["error_id"])
+	}
+}
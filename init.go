@@ -1,11 +1,12 @@
 package errs
 
 import (
-	"bytes"
 	"fmt"
 	"log/slog"
 	"os"
-	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
 )
 
 // Define custom logging levels.
@@ -18,20 +19,33 @@ const (
 	DefaultLogFile         = "log/logger.json" // Default log file path.
 )
 
-// Global variable to hold the bot instance
-var bot *broadcastBot
+// settings is an immutable snapshot of the configuration of the package.
+// Every change stores a new snapshot, so that entries being logged concurrently
+// keep a consistent view of the configuration without locking.
+type settings struct {
+	service   string
+	separator string
+	level     slog.Level // Minimum level written by the loggers.
+	logTypes  []LogType
+	logFile   *logFile
+	rotation  RotationParams           // Rotation of the log files, see SetLogRotation.
+	policy    []PolicyRule             // Levels and alert decisions of errors, see SetPolicy.
+	loggers   map[LogType]*slog.Logger // One logger per log type, see build.
+	sinks     []logSink                // Loggers for logTypes.
+}
 
 // Variables to manage the loggers and logging levels.
 var (
-	sTitle string
-	separator   string
-	fileLogger  *os.File
-	jsonLogger  *slog.Logger
-	jsonBuf     *bytes.Buffer
-
-	slogLoggers []*slog.Logger // List of loggers.
+	config   atomic.Pointer[settings]
+	configMu sync.Mutex // Serializes changes to config.
 )
 
+// logSink is a configured logger together with the log type it was created for.
+type logSink struct {
+	name   LogType
+	logger *slog.Logger
+}
+
 // init initializes loggers when the package is first loaded.
 // It sets up the loggers using the LogTypeJSON constant by default.
 func init() {
@@ -39,42 +53,114 @@ func init() {
 	SetSupervisorErr(" ---> ")
 }
 
+// currentSettings returns the current configuration snapshot. It must not be modified.
+func currentSettings() *settings {
+	if s := config.Load(); s != nil {
+		return s
+	}
+	return &settings{level: slog.LevelError}
+}
+
+// updateSettings applies fn to a copy of the current configuration and stores the copy.
+// Nothing is stored if fn fails. Slices and maps of the copy are shared with the
+// current snapshot, so fn must replace them instead of modifying them.
+func updateSettings(fn func(s *settings) error) error {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	s := *currentSettings()
+	if err := fn(&s); err != nil {
+		return err
+	}
+	s.build()
+	config.Store(&s)
+	return nil
+}
+
+// build creates the loggers of the snapshot for its level and log file.
+func (s *settings) build() {
+	s.loggers = map[LogType]*slog.Logger{
+		LogTypeJSON: newJSONLogger(os.Stderr, s.level),
+		LogTypeText: newTextLogger(os.Stderr, s.level),
+	}
+	if s.logFile != nil {
+		s.loggers[LogTypeFile] = newFileLogger(s.logFile, s.level)
+	}
+	s.sinks = s.sinksFor(s.logTypes)
+}
+
+// sinksFor returns the loggers of the snapshot for the log types.
+func (s *settings) sinksFor(types []LogType) []logSink {
+	sinks := make([]logSink, 0, len(types))
+	for _, t := range types {
+		if logger, ok := s.loggers[t]; ok {
+			sinks = append(sinks, logSink{t, logger})
+		}
+	}
+	return sinks
+}
+
 // SetLogTypes configures the logging types to be used (e.g., JSON, text, file).
 // Accepts a variadic list of log types and sets up the corresponding loggers.
 //
 // The function iterates through the provided log types and creates a new logger for each type.
 // It supports three types: LogTypeJSON, LogTypeText, and LogTypeFile.
 //
-// For LogTypeJSON, it creates a JSON logger using the newJSONLogger function and appends it to the slogLoggers slice.
-// For LogTypeText, it creates a text logger using the newTextLogger function and appends it to the slogLoggers slice.
-// For LogTypeFile, it checks if the fileLogger is nil. If it is, it calls the SetLogFile function with the DefaultLogFile path.
-// Then, it creates a file logger using the newFileLogger function and appends it to the slogLoggers slice.
+// For LogTypeJSON and LogTypeText, it creates a logger writing to stderr.
+// For LogTypeFile, it opens the DefaultLogFile path if no file was set with SetLogFile,
+// and creates a logger writing to the file.
 //
 // If an unknown log type is encountered, it prints an error message to the console.
+// The new loggers apply to the entries logged from then on; entries already being logged
+// finish with the loggers they started with.
 //
 // Note: The function does not return any value.
 func SetLogTypes(types ...LogType) {
-	slogLoggers = []*slog.Logger{}
+	_ = updateSettings(func(s *settings) error {
+		s.logTypes = knownLogTypes(types)
+		s.ensureLogFile(s.logTypes)
+		return nil
+	})
+}
+
+// knownLogTypes returns the log types that are supported, printing the others.
+func knownLogTypes(types []LogType) []LogType {
+	known := make([]LogType, 0, len(types))
 	for _, t := range types {
 		switch t {
-		case LogTypeJSON:
-			jsonLogger = newJSONLogger(os.Stderr)
-			slogLoggers = append(slogLoggers, jsonLogger)
-		case LogTypeText:
-			slogLoggers = append(slogLoggers, newTextLogger(os.Stderr))
-		case LogTypeFile:
-			if fileLogger == nil {
-				_ = SetLogFile(DefaultLogFile)
-			}
-			slogLoggers = append(slogLoggers, newFileLogger(fileLogger))
+		case LogTypeJSON, LogTypeText, LogTypeFile:
+			known = append(known, t)
 		default:
 			fmt.Printf("Unknown log type: %s\n", t)
 		}
 	}
+	return known
+}
+
+// ensureLogFile opens the default log file if one of the log types needs a file and none is set.
+// A file that cannot be opened leaves the file logger out.
+func (s *settings) ensureLogFile(types []LogType) {
+	if s.logFile == nil && slices.Contains(types, LogTypeFile) {
+		s.logFile, _ = openLogFile(DefaultLogFile, s.rotation)
+	}
+}
+
+// useLogTypes returns the supported log types of a logger, opening the default log file if they need one.
+func useLogTypes(types ...LogType) []LogType {
+	types = knownLogTypes(types)
+	if currentSettings().logFile == nil && slices.Contains(types, LogTypeFile) {
+		_ = updateSettings(func(s *settings) error {
+			s.ensureLogFile(types)
+			return nil
+		})
+	}
+	return types
 }
 
 // SetLogFile sets the log file path and configures the file logger.
 // This function should be called only for the Master level to log errors to a file.
+// The file is reopened even if the path is unchanged, which completes a log rotation,
+// and the previous file is closed once the entries being written to it are done.
 //
 // Parameters:
 // filePath (string): The path to the log file. If the directory does not exist, it will be created.
@@ -83,26 +169,64 @@ func SetLogTypes(types ...LogType) {
 // error: An error if the file path is invalid, if the log directory cannot be created, or if the log file cannot be opened.
 // If no error occurs, it returns nil.
 func SetLogFile(filePath string) error {
-	if filePath == "" {
-		return New("invalid file path")
+	file, err := openLogFile(filePath, currentSettings().rotation)
+	if err != nil {
+		return err
 	}
 
-	// Ensure the directory for the log file exists.
-	dir := filepath.Dir(filePath)
-	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
-		return Wrap(err, "failed to create log directory")
+	var old *logFile
+	_ = updateSettings(func(s *settings) error {
+		old, s.logFile = s.logFile, file
+		return nil
+	})
+	old.retire()
+
+	return nil
+}
+
+// acquireSettings returns the current configuration snapshot with its log file held open,
+// so that a replaced file is not closed while entries are written to it. The caller must
+// call release once it is done writing.
+func acquireSettings() *settings {
+	for {
+		s := currentSettings()
+		if s.logFile == nil || s.logFile.acquire() {
+			return s
+		}
+		// The file was replaced after the snapshot was loaded, so a newer snapshot is stored.
 	}
+}
 
-	// Attempt to create or open the specified file with appropriate flags and permissions.
-	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
-	if err != nil {
-		return Wrap(err, "failed to set log file")
+// release releases the log file of a snapshot returned by acquireSettings.
+func (s *settings) release() {
+	if s.logFile != nil {
+		s.logFile.release()
 	}
+}
 
-	// Create the file-based logger and update loggers.
-	fileLogger = file
+// logInternal writes a message about the package itself, such as a failed delivery,
+// to the loggers of the default configuration.
+func logInternal(msg string, args ...any) {
+	s := acquireSettings()
+	defer s.release()
 
-	return nil
+	for _, sink := range s.sinks {
+		sink.logger.Error(msg, args...)
+	}
+}
+
+// SetLevel sets the minimum level of the entries written by the loggers configured with
+// SetLogTypes and NewLogger. The default is slog.LevelError. It applies to every entry,
+// so lower it to see entries demoted by a policy rule or the Level option, see SetPolicy.
+// The broadcast bots are not affected.
+//
+// Parameters:
+//   - level: The minimum level to write.
+func SetLevel(level slog.Level) {
+	_ = updateSettings(func(s *settings) error {
+		s.level = level
+		return nil
+	})
 }
 
 // SetSupervisorErr sets the separator used for logging errors from the supervisor.
@@ -114,5 +238,16 @@ func SetLogFile(filePath string) error {
 // Return:
 // None.
 func SetSupervisorErr(sep string) {
-	separator = sep
+	_ = updateSettings(func(s *settings) error {
+		s.separator = sep
+		return nil
+	})
+}
+
+// setServiceName sets the service name of the entries of loggers without their own.
+func setServiceName(name string) {
+	_ = updateSettings(func(s *settings) error {
+		s.service = name
+		return nil
+	})
 }
@@ -2,6 +2,7 @@ package errs
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -16,8 +17,8 @@ func TestSetLogTypes(t *testing.T) {
 	// Test setting log types
 	SetLogTypes(LogTypeJSON, LogTypeText, LogTypeFile)
 
-	if len(slogLoggers) != 3 {
-		t.Fatalf("Expected 3 loggers, got %d", len(slogLoggers))
+	if sinks := currentSettings().sinks; len(sinks) != 3 {
+		t.Fatalf("Expected 3 loggers, got %d", len(sinks))
 	}
 }
 
@@ -35,3 +36,31 @@ func TestSetLogFile(t *testing.T) {
 	// Clean up
 	os.Remove("test.log")
 }
+
+func TestSetLogFile_InFlight(t *testing.T) {
+	restoreConfig(t)
+	dir := t.TempDir()
+	if err := SetLogFile(filepath.Join(dir, "old.json")); err != nil {
+		t.Fatal("Expected no error, got", err)
+	}
+
+	// An entry being written holds the old file while it is replaced.
+	s := acquireSettings()
+	old := s.logFile
+	if err := SetLogFile(filepath.Join(dir, "new.json")); err != nil {
+		t.Fatal("Expected no error, got", err)
+	}
+	if _, err := old.Write([]byte("in flight\n")); err != nil {
+		t.Fatal("Expected the replaced file to stay open for the writer, got", err)
+	}
+
+	s.release()
+	if _, err := old.Write([]byte("late\n")); err == nil {
+		t.Fatal("Expected the replaced file to be closed once its last writer is done")
+	}
+	if s := acquireSettings(); s.logFile == old {
+		t.Fatal("Expected a new snapshot after the file was replaced")
+	} else {
+		s.release()
+	}
+}
@@ -2,6 +2,7 @@ package errs
 
 import (
 	"fmt"
+	"log/slog"
 	"strings"
 )
 
@@ -19,8 +20,20 @@ import (
 func Join(sep string, errors ...error) error {
 	var origErr strings.Builder
 	var message strings.Builder
+	var code string
+	var stack []uintptr
+	var attrs []slog.Attr
+	var causes []error
 	for _, err := range errors {
 		if err != nil {
+			causes = append(causes, err)
+			if code == "" {
+				code = Code(err) // Keep the code of the first error that has one.
+			}
+			if stack == nil {
+				stack = stackOf(err) // Keep the stack of the first error that has one.
+			}
+			attrs = append(attrs, errorAttrs(err)...)
 			if origErr.Len() > 0 {
 				origErr.WriteString(sep) // Append sep between error messages.
 				message.WriteString(sep) // Append sep between error messages.
@@ -33,10 +46,14 @@ func Join(sep string, errors ...error) error {
 	if origErr.Len() == 0 {
 		return nil
 	}
-	
+
 	return &errorString{
 		origErr: origErr.String(),
 		message: message.String(),
+		code:    code,
+		stack:   stack,
+		attrs:   attrs,
+		causes:  causes,
 	}
 }
 
@@ -0,0 +1,481 @@
+package errs
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ConfigFileEnv is the environment variable LoadConfig reads the configuration file path from.
+	ConfigFileEnv = "ERRS_CONFIG_FILE"
+	// configFileFlag is the flag RegisterFlags registers for the configuration file path.
+	configFileFlag = "errs-config-file"
+	// envPrefix is the prefix of the environment variables read by LoadConfig.
+	envPrefix = "ERRS_"
+	// flagPrefix is the prefix of the flags registered by RegisterFlags.
+	flagPrefix = "errs-"
+)
+
+// Setup is the whole configuration of the package as read by LoadConfig: the runtime
+// configuration of the default logger, the log file rotation, the broadcast bot and
+// the throttles of the sinks. Fields are named after their json tags in every source.
+type Setup struct {
+	Config                              // Log types, log file, level, separator, service name and routes.
+	Rotation  RotationParams            `json:"rotation"`            // Rotation of the log file, see SetLogRotation.
+	Bot       *BotConfig                `json:"bot,omitempty"`       // Bot attached to the default logger, if any.
+	Throttles map[string]ThrottleParams `json:"throttles,omitempty"` // Deduplication and rate limits by sink name, see SetThrottle.
+}
+
+// BotConfig configures the broadcast bot created by LoadConfig, see BroadcastBotParams.
+// Its routes are the routes of the Config.
+type BotConfig struct {
+	Token          string          `json:"token,omitempty"`
+	TokenFile      string          `json:"token_file,omitempty"` // File the token is read from, such as a mounted secret.
+	ChatIDs        []int64         `json:"chat_ids"`
+	ParseMode      ParseMode       `json:"parse_mode,omitempty"`
+	LongMessages   LongMessageMode `json:"long_messages,omitempty"`
+	TrimSpace      bool            `json:"trim_space,omitempty"`
+	APIEndpoint    string          `json:"api_endpoint,omitempty"`
+	Lazy           bool            `json:"lazy,omitempty"`
+	Template       string          `json:"template,omitempty"`
+	TimeZone       string          `json:"time_zone,omitempty"` // IANA time zone name, such as "Europe/Berlin".
+	TimeFormat     string          `json:"time_format,omitempty"`
+	EditRepeats    time.Duration   `json:"edit_repeats,omitempty"`
+	Digest         DigestParams    `json:"digest"`
+	Commands       bool            `json:"commands,omitempty"`
+	CommandChatIDs []int64         `json:"command_chat_ids,omitempty"`
+	Buttons        bool            `json:"buttons,omitempty"`
+	Verify         bool            `json:"verify,omitempty"`
+	TestMessage    bool            `json:"test_message,omitempty"`
+}
+
+// configField is a field of the Setup that can be set from an environment variable or a flag.
+type configField struct {
+	path  string  // Path of the field in a configuration file, such as bot.chat_ids.
+	index [][]int // Field indexes from the Setup to the field, one per struct.
+	typ   reflect.Type
+}
+
+// configFlag is a flag registered by RegisterFlags.
+type configFlag struct {
+	field *configField // Nil for the configuration file flag.
+	value string
+}
+
+// LoadConfig builds the whole setup of the package from a YAML or JSON file, from ERRS_*
+// environment variables and from the flags registered with RegisterFlags, in increasing order
+// of precedence: it sets the log types, the log file and its rotation, the level, the separator
+// and the throttles, and attaches a broadcast bot to the default logger if one is configured.
+//
+// Every field of a file has an environment variable and a flag named after its path:
+// bot.chat_ids is ERRS_BOT_CHAT_IDS and -errs-bot-chat-ids. Lists of scalars are separated
+// by commas and other lists and objects are written as JSON, for example
+// ERRS_THROTTLES='{"TELEGRAM": {"window": "5m"}}'. Fields that are not set anywhere keep
+// their current values. The configuration is validated before anything is changed.
+//
+// Parameters:
+//   - path: The configuration file, YAML unless it ends in .json. Empty uses the -errs-config-file
+//     flag or the ERRS_CONFIG_FILE environment variable, and no file if neither is set.
+//   - fs: The flags registered with RegisterFlags, after they are parsed. Nil ignores flags.
+//
+// Returns:
+//   - An error naming the path of every invalid value, or an error applying the configuration.
+func LoadConfig(path string, fs *flag.FlagSet) error {
+	s, err := ReadConfig(path, fs)
+	if err != nil {
+		return err
+	}
+	return s.Apply()
+}
+
+// ReadConfig reads and validates the setup like LoadConfig, without applying it.
+// The token of the bot is read from its token file.
+//
+// Parameters:
+//   - path: The configuration file, see LoadConfig.
+//   - fs: The flags registered with RegisterFlags, after they are parsed. Nil ignores flags.
+//
+// Returns:
+//   - The setup, starting from the current configuration, or an error naming the path of every invalid value.
+func ReadConfig(path string, fs *flag.FlagSet) (Setup, error) {
+	s := currentSetup()
+
+	flags := map[string]*configFlag{}
+	if fs != nil {
+		fs.Visit(func(f *flag.Flag) {
+			if cf, ok := f.Value.(*configFlag); ok {
+				flags[f.Name] = cf
+			}
+		})
+	}
+
+	if cf, ok := flags[configFileFlag]; ok && path == "" {
+		path = cf.value
+	}
+	if path == "" {
+		path = os.Getenv(ConfigFileEnv)
+	}
+	if path != "" {
+		if err := readConfigFile(path, &s); err != nil {
+			return s, err
+		}
+	}
+
+	v := reflect.ValueOf(&s).Elem()
+	for _, field := range configFields() {
+		if text, ok := os.LookupEnv(field.env()); ok {
+			if err := decodeText(field.env(), field.value(v), text); err != nil {
+				return s, err
+			}
+		}
+		if cf, ok := flags[field.flag()]; ok {
+			if err := decodeText("-"+field.flag(), field.value(v), cf.value); err != nil {
+				return s, err
+			}
+		}
+	}
+
+	if err := s.resolve(); err != nil {
+		return s, err
+	}
+	return s, s.validate()
+}
+
+// RegisterFlags registers a flag for every field of the Setup, such as -errs-bot-chat-ids,
+// and -errs-config-file for the configuration file. Pass the flag set to LoadConfig once it is parsed.
+//
+// Parameters:
+//   - fs: The flag set to register the flags with.
+func RegisterFlags(fs *flag.FlagSet) {
+	fs.Var(&configFlag{}, configFileFlag, "errs: configuration file, like "+ConfigFileEnv)
+	for _, field := range configFields() {
+		fs.Var(&configFlag{field: field}, field.flag(), fmt.Sprintf("errs: sets %s, like %s", field.path, field.env()))
+	}
+}
+
+// Apply applies the setup: the rotation, the bot with its routes, the configuration and the throttles.
+// A bot attached to the default logger before is closed and replaced. The new bot is created and
+// the log file opened before anything is replaced, so a setup that fails changes nothing.
+//
+// Returns:
+//   - An error if the setup is invalid, the log file cannot be opened or the bot cannot be created.
+func (s Setup) Apply() error {
+	if err := s.validate(); err != nil {
+		return err
+	}
+
+	c := s.Config
+	var bot *BroadcastBot
+	if s.Bot != nil {
+		params, err := s.Bot.params(c)
+		if err != nil {
+			return err
+		}
+		if bot, err = NewBot(params); err != nil {
+			return Wrap(err, "bot")
+		}
+		c.Routes, c.DefaultRoute = nil, nil
+	}
+	return s.apply(c, bot)
+}
+
+// currentSetup returns a setup with the current configuration and rotation, without routes,
+// so that a setup decoded over it only replaces the routes if it has some.
+func currentSetup() Setup {
+	s := Setup{Config: CurrentConfig(), Rotation: currentSettings().rotation}
+	s.Routes, s.DefaultRoute = nil, nil
+	return s
+}
+
+// apply applies the rotation, the configuration c, the bot if it is not nil and the throttles
+// of the setup. The setup must be valid, so that the throttles cannot fail once the rest is applied.
+func (s Setup) apply(c Config, bot *BroadcastBot) error {
+	if err := applyConfig(c, &s.Rotation, bot); err != nil {
+		return err
+	}
+
+	for sink, params := range s.Throttles {
+		if err := SetThrottle(sink, params); err != nil {
+			return WrapF(err, "throttles.%s", sink)
+		}
+	}
+	return nil
+}
+
+// readConfigFile decodes a YAML or JSON configuration file over the setup.
+func readConfigFile(path string, s *Setup) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Wrap(err, "failed to read config file")
+	}
+
+	var tree any
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = unmarshalTree(data, &tree)
+	} else {
+		err = yaml.Unmarshal(data, &tree)
+	}
+	if err != nil {
+		return WrapF(err, "invalid config file %s", path)
+	}
+
+	if err := decodeTree("", reflect.ValueOf(s).Elem(), tree); err != nil {
+		return WrapF(err, "invalid config file %s", path)
+	}
+	return nil
+}
+
+// resolve reads the token of the bot from its token file.
+func (s *Setup) resolve() error {
+	if s.Bot == nil || s.Bot.TokenFile == "" {
+		return nil
+	}
+	if s.Bot.Token != "" {
+		return New("bot.token_file: cannot be set together with bot.token")
+	}
+
+	data, err := os.ReadFile(s.Bot.TokenFile)
+	if err != nil {
+		return Wrap(err, "bot.token_file: failed to read the token")
+	}
+	s.Bot.Token = strings.TrimSpace(string(data))
+	return nil
+}
+
+// validate checks every value of the setup, reporting all invalid values with their paths.
+func (s Setup) validate() error {
+	var errs []error
+	invalid := func(format string, args ...any) {
+		errs = append(errs, NewF(format, args...))
+	}
+
+	for i, t := range s.LogTypes {
+		if !slices.Contains([]LogType{LogTypeJSON, LogTypeText, LogTypeFile}, t) {
+			invalid("log_types[%d]: unknown log type %q", i, t)
+		}
+	}
+	if s.Rotation.MaxSizeMB < 0 || s.Rotation.MaxBackups < 0 || s.Rotation.MaxAge < 0 {
+		invalid("rotation: must not be negative")
+	}
+
+	mode := ParseModeMarkdown
+	if s.Bot != nil {
+		mode = s.Bot.validate(s.Config, invalid)
+	} else if bot := Default().Bot(); bot != nil {
+		mode = bot.parseMode
+	} else if s.Routes != nil || s.DefaultRoute != nil {
+		invalid("routes: a bot is required")
+	}
+
+	for i, r := range s.Routes {
+		path := fmt.Sprintf("routes[%d]", i)
+		if r.Message != "" {
+			if _, err := regexp.Compile(r.Message); err != nil {
+				invalid("%s.message: invalid pattern %q", path, r.Message)
+			}
+		}
+		if len(r.Chats) == 0 && !r.CopyToAll {
+			invalid("%s.chats: must not be empty", path)
+		}
+		validateChats(path+".chats", r.Chats, invalid)
+		validateTemplate(path+".template", r.Template, mode, invalid)
+	}
+	validateChats("default_route", s.DefaultRoute, invalid)
+
+	sinks := []string{string(LogTypeJSON), string(LogTypeText), string(LogTypeFile), BotSinkName}
+	for sink, p := range s.Throttles {
+		if !slices.Contains(sinks, strings.ToUpper(sink)) {
+			invalid("throttles.%s: unknown sink, expected one of %s", sink, strings.Join(sinks, ", "))
+		}
+		if p.Window < 0 || p.Limit < 0 || p.Rate < 0 || p.Burst < 0 {
+			invalid("throttles.%s: must not be negative", sink)
+		}
+	}
+
+	if err := Join(" && ", errs...); err != nil {
+		return Wrap(err, "invalid configuration")
+	}
+	return nil
+}
+
+// validate checks the bot configuration and returns its parse mode.
+func (b *BotConfig) validate(c Config, invalid func(format string, args ...any)) ParseMode {
+	if b.Token == "" {
+		invalid("bot.token: must be set, or bot.token_file")
+	}
+	if len(b.ChatIDs) == 0 && len(c.Routes) == 0 && len(c.DefaultRoute) == 0 {
+		invalid("bot.chat_ids: must not be empty without routes")
+	}
+	for i, id := range b.ChatIDs {
+		if id == 0 {
+			invalid("bot.chat_ids[%d]: must not be zero", i)
+		}
+	}
+
+	mode := b.ParseMode
+	switch mode {
+	case "":
+		mode = ParseModeMarkdown
+	case ParseModeMarkdown, ParseModeMarkdownV2, ParseModeHTML, ParseModeText:
+	default:
+		invalid("bot.parse_mode: unknown parse mode %q", b.ParseMode)
+	}
+	switch b.LongMessages {
+	case "", LongMessageSplit, LongMessageDocument:
+	default:
+		invalid("bot.long_messages: unknown mode %q", b.LongMessages)
+	}
+	if b.TimeZone != "" {
+		if _, err := time.LoadLocation(b.TimeZone); err != nil {
+			invalid("bot.time_zone: unknown time zone %q", b.TimeZone)
+		}
+	}
+	if b.EditRepeats < 0 || b.Digest.Window < 0 {
+		invalid("bot: durations must not be negative")
+	}
+	validateTemplate("bot.template", b.Template, mode, invalid)
+	return mode
+}
+
+// params returns the parameters of the bot, with the routes and service name of the configuration.
+func (b *BotConfig) params(c Config) (BroadcastBotParams, error) {
+	routes, err := c.routes()
+	if err != nil {
+		return BroadcastBotParams{}, err
+	}
+
+	params := BroadcastBotParams{
+		ServiceName:    c.ServiceName,
+		Token:          b.Token,
+		ChatIDs:        b.ChatIDs,
+		TrimSpace:      b.TrimSpace,
+		LongMessages:   b.LongMessages,
+		ParseMode:      b.ParseMode,
+		Routes:         routes,
+		DefaultRoute:   c.DefaultRoute,
+		APIEndpoint:    b.APIEndpoint,
+		Lazy:           b.Lazy,
+		Digest:         b.Digest,
+		Commands:       b.Commands,
+		CommandChatIDs: b.CommandChatIDs,
+		Buttons:        b.Buttons,
+		EditRepeats:    b.EditRepeats,
+		Template:       b.Template,
+		TimeFormat:     b.TimeFormat,
+		Verify:         b.Verify,
+		TestMessage:    b.TestMessage,
+	}
+	if b.TimeZone != "" {
+		if params.TimeZone, err = time.LoadLocation(b.TimeZone); err != nil {
+			return params, Wrap(err, "bot.time_zone")
+		}
+	}
+	return params, nil
+}
+
+// validateChats checks that every chat has an ID.
+func validateChats(path string, chats []ChatTarget, invalid func(format string, args ...any)) {
+	for i, chat := range chats {
+		if chat.ChatID == 0 {
+			invalid("%s[%d].chat_id: must be set", path, i)
+		}
+	}
+}
+
+// validateTemplate checks that a notification template parses.
+func validateTemplate(path, text string, mode ParseMode, invalid func(format string, args ...any)) {
+	if text == "" {
+		return
+	}
+	if _, err := newNotificationTemplate(text, mode, nil, ""); err != nil {
+		invalid("%s: %s", path, Unwrap(err))
+	}
+}
+
+// configFields returns the fields of the Setup that can be set from the environment and flags:
+// every field that is not a struct, with the fields of nested structs named after their path.
+func configFields() []*configField {
+	var fields []*configField
+	var walk func(t reflect.Type, path string, index [][]int)
+	walk = func(t reflect.Type, path string, index [][]int) {
+		byName := structFields(t)
+		names := make([]string, 0, len(byName))
+		for name := range byName {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+
+		for _, name := range names {
+			i := append(slices.Clip(index), byName[name])
+			ft := t.FieldByIndex(byName[name]).Type
+			if ft.Kind() == reflect.Pointer && ft.Elem().Kind() == reflect.Struct {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				walk(ft, joinPath(path, name), i)
+				continue
+			}
+			fields = append(fields, &configField{path: joinPath(path, name), index: i, typ: ft})
+		}
+	}
+	walk(reflect.TypeOf(Setup{}), "", nil)
+	return fields
+}
+
+// value returns the field in the setup v, allocating the structs on the way.
+func (f *configField) value(v reflect.Value) reflect.Value {
+	for _, index := range f.index {
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.FieldByIndex(index)
+	}
+	return v
+}
+
+// env returns the environment variable of the field, such as ERRS_BOT_CHAT_IDS.
+func (f *configField) env() string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(f.path, ".", "_"))
+}
+
+// flag returns the flag name of the field, such as errs-bot-chat-ids.
+func (f *configField) flag() string {
+	return flagPrefix + strings.NewReplacer(".", "-", "_", "-").Replace(f.path)
+}
+
+// String implements flag.Value.
+func (f *configFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.value
+}
+
+// Set implements flag.Value.
+func (f *configFlag) Set(value string) error {
+	if f.field != nil {
+		// Check the value now, so that the flag package reports it with the usage.
+		if err := decodeText("-"+f.field.flag(), reflect.New(f.field.typ).Elem(), value); err != nil {
+			return err
+		}
+	}
+	f.value = value
+	return nil
+}
+
+// IsBoolFlag lets boolean fields be set with just the flag name.
+func (f *configFlag) IsBoolFlag() bool {
+	return f.field != nil && f.field.typ.Kind() == reflect.Bool
+}
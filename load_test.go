@@ -0,0 +1,223 @@
+package errs
+
+import (
+	"flag"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeConfigFile writes a configuration file in a temporary directory.
+func writeConfigFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal("Expected no error, got", err)
+	}
+	return path
+}
+
+func TestReadConfig(t *testing.T) {
+	tokenFile := writeConfigFile(t, "token", "123:secret\n")
+	path := writeConfigFile(t, "errs.yaml", `
+service_name: billing
+log_types: [JSON, FILE]
+log_file: log/billing.json
+level: warn
+separator: " | "
+rotation:
+  max_size_mb: 100
+  max_backups: 3
+bot:
+  token_file: `+tokenFile+`
+  chat_ids: [-1001]
+  template: "{{ .Message }}"
+  edit_repeats: 10m
+routes:
+  - codes: [payment_declined]
+    chats: [{chat_id: -1002, thread_id: 7}]
+throttles:
+  TELEGRAM: {window: 5m, limit: 3}
+`)
+	t.Setenv("ERRS_SEPARATOR", " / ")
+	t.Setenv("ERRS_BOT_CHAT_IDS", "-1001,-1003")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	RegisterFlags(fs)
+	if err := fs.Parse([]string{"-errs-config-file", path, "-errs-level", "DEBUG", "-errs-bot-buttons"}); err != nil {
+		t.Fatal("Expected no error, got", err)
+	}
+
+	s, err := ReadConfig("", fs)
+	if err != nil {
+		t.Fatal("Expected no error, got", Unwrap(err))
+	}
+
+	if s.ServiceName != "billing" || s.LogFile != "log/billing.json" || !reflect.DeepEqual(s.LogTypes, []LogType{LogTypeJSON, LogTypeFile}) {
+		t.Fatalf("Expected the file to be read, got %+v", s.Config)
+	}
+	if s.Separator != " / " || s.Level != slog.LevelDebug {
+		t.Fatalf("Expected the environment and the flags to override the file, got %q %v", s.Separator, s.Level)
+	}
+	if s.Rotation != (RotationParams{MaxSizeMB: 100, MaxBackups: 3}) {
+		t.Fatalf("Unexpected rotation %+v", s.Rotation)
+	}
+	if s.Bot == nil || s.Bot.Token != "123:secret" || !reflect.DeepEqual(s.Bot.ChatIDs, []int64{-1001, -1003}) || !s.Bot.Buttons || s.Bot.EditRepeats != 10*time.Minute {
+		t.Fatalf("Unexpected bot %+v", s.Bot)
+	}
+	if len(s.Routes) != 1 || s.Routes[0].Chats[0] != (ChatTarget{ChatID: -1002, ThreadID: 7}) {
+		t.Fatalf("Unexpected routes %+v", s.Routes)
+	}
+	if s.Throttles[BotSinkName] != (ThrottleParams{Window: 5 * time.Minute, Limit: 3}) {
+		t.Fatalf("Unexpected throttles %+v", s.Throttles)
+	}
+}
+
+func TestReadConfig_Invalid(t *testing.T) {
+	path := writeConfigFile(t, "errs.json", `{
+		"log_types": ["XML"],
+		"bot": {"token": "1:x", "chat_ids": [0], "parse_mode": "RTF", "time_zone": "Mars/Olympus", "template": "{{ .Nope"},
+		"routes": [{"message": "(", "chats": [{"thread_id": 1}]}],
+		"throttles": {"SLACK": {"window": "1m"}, "FILE": {"limit": -1}}
+	}`)
+
+	_, err := ReadConfig(path, nil)
+	if err == nil {
+		t.Fatal("Expected the configuration to be invalid")
+	}
+	for _, want := range []string{
+		`log_types[0]: unknown log type "XML"`,
+		"bot.chat_ids[0]: must not be zero",
+		`bot.parse_mode: unknown parse mode "RTF"`,
+		`bot.time_zone: unknown time zone "Mars/Olympus"`,
+		"bot.template:",
+		`routes[0].message: invalid pattern "("`,
+		"routes[0].chats[0].chat_id: must be set",
+		"throttles.SLACK: unknown sink",
+		"throttles.FILE: must not be negative",
+	} {
+		if !strings.Contains(Unwrap(err), want) {
+			t.Errorf("Expected %q in %s", want, Unwrap(err))
+		}
+	}
+
+	if _, err := ReadConfig(writeConfigFile(t, "errs.yaml", "bot: {chat_ids: [1, two]}"), nil); err == nil || !strings.Contains(Unwrap(err), `bot.chat_ids[1]: expected an integer, got "two"`) {
+		t.Fatalf("Expected the path of the invalid value, got %v", err)
+	}
+	if _, err := ReadConfig(writeConfigFile(t, "errs.yaml", "bot: {token: x, token_file: y, chat_ids: [1]}"), nil); err == nil {
+		t.Fatal("Expected the token and the token file to be exclusive")
+	}
+	if _, err := ReadConfig(writeConfigFile(t, "errs.yaml", "routes: [{chats: [{chat_id: 1}]}]"), nil); err == nil || !strings.Contains(Unwrap(err), "routes: a bot is required") {
+		t.Fatalf("Expected routes to need a bot, got %v", err)
+	}
+
+	t.Setenv("ERRS_ROTATION_MAX_BACKUPS", "many")
+	if _, err := ReadConfig("", nil); err == nil || !strings.HasPrefix(err.Error(), "ERRS_ROTATION_MAX_BACKUPS: expected an integer") {
+		t.Fatalf("Expected the environment variable to be named, got %v", err)
+	}
+}
+
+func TestRegisterFlags(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	RegisterFlags(fs)
+
+	if fs.Lookup("errs-bot-chat-ids") == nil || fs.Lookup("errs-config-file") == nil {
+		t.Fatal("Expected the flags to be registered")
+	}
+	if err := fs.Parse([]string{"-errs-bot-chat-ids", "1,x"}); err == nil || !strings.Contains(err.Error(), "-errs-bot-chat-ids[1]") {
+		t.Fatalf("Expected the invalid flag to be reported, got %v", err)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	restoreConfig(t)
+	f := newFakeBotAPI(t)
+	t.Cleanup(func() {
+		if old := Default().SetBot(nil); old != nil {
+			old.Close()
+		}
+		_ = SetThrottle(string(LogTypeText), ThrottleParams{})
+		_ = SetLogRotation(RotationParams{})
+	})
+
+	path := writeConfigFile(t, "errs.yaml", `
+service_name: loaded
+log_types: [TEXT]
+log_file: `+filepath.Join(t.TempDir(), "errors.json")+`
+separator: " ~ "
+rotation: {max_size_mb: 1}
+bot:
+  token: `+fakeBotToken+`
+  api_endpoint: `+f.URL+`
+  lazy: true
+default_route: [{chat_id: 9}]
+throttles:
+  TEXT: {window: 1m}
+`)
+	if err := LoadConfig(path, nil); err != nil {
+		t.Fatal("Expected no error, got", Unwrap(err))
+	}
+
+	c := CurrentConfig()
+	if c.ServiceName != "loaded" || c.Separator != " ~ " || !reflect.DeepEqual(c.LogTypes, []LogType{LogTypeText}) || !strings.HasSuffix(c.LogFile, "errors.json") {
+		t.Fatalf("Expected the configuration to be applied, got %+v", c)
+	}
+	if currentSettings().rotation.MaxSizeMB != 1 {
+		t.Fatal("Expected the rotation to be applied")
+	}
+	bot := Default().Bot()
+	if bot == nil || !reflect.DeepEqual(bot.route(Entry{}), []ChatTarget{{ChatID: 9}}) {
+		t.Fatal("Expected the bot to be attached with its default route")
+	}
+	throttles.RLock()
+	_, ok := throttles.bySink[string(LogTypeText)]
+	throttles.RUnlock()
+	if !ok {
+		t.Fatal("Expected the throttle to be set")
+	}
+}
+
+func TestSetup_ApplyFailure(t *testing.T) {
+	restoreConfig(t)
+	f := newFakeBotAPI(t)
+	setupFakeBot(t, f, BroadcastBotParams{ChatIDs: []int64{1}, Lazy: true})
+	bot := Default().Bot()
+
+	// A regular file where the directory of the log file should be makes opening it fail.
+	blocker := filepath.Join(t.TempDir(), "blocker")
+	if err := os.WriteFile(blocker, nil, 0o666); err != nil {
+		t.Fatal(err)
+	}
+	s := currentSetup()
+	s.ServiceName = "half"
+	s.LogFile = filepath.Join(blocker, "errors.json")
+	s.Rotation = RotationParams{MaxSizeMB: 5}
+	s.Bot = &BotConfig{Token: fakeBotToken, ChatIDs: []int64{2}, APIEndpoint: f.URL, Lazy: true}
+
+	if err := s.Apply(); err == nil {
+		t.Fatal("Expected an error for a log file that cannot be opened")
+	}
+	if Default().Bot() != bot || bot.ctx.Err() != nil {
+		t.Fatal("Expected the previous bot to stay attached and open")
+	}
+	if CurrentConfig().ServiceName == "half" || currentSettings().rotation.MaxSizeMB == 5 {
+		t.Fatal("Expected nothing to be changed by a setup that failed")
+	}
+}
+
+func TestApplyConfigFile_YAML(t *testing.T) {
+	restoreConfig(t)
+
+	path := writeConfigFile(t, "errs.yml", "separator: \" :: \"\nbot: {token: ignored}\n")
+	if err := ApplyConfigFile(path); err != nil {
+		t.Fatal("Expected no error, got", Unwrap(err))
+	}
+	if CurrentConfig().Separator != " :: " || Default().Bot() != nil {
+		t.Fatal("Expected the configuration to be applied without creating a bot")
+	}
+}
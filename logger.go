@@ -1,32 +1,101 @@
 package errs
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"io"
 	"log"
 	"log/slog"
-	"os"
 	"strings"
+	"sync"
 
 	"github.com/fatih/color"
 )
 
-// newJSONLogger creates a new JSON logger for structured logging with no source path.
-func newJSONLogger(output io.Writer) *slog.Logger {
-	jsonBuf = &bytes.Buffer{}
-	mw := io.MultiWriter(output, jsonBuf)
+// Logger logs errors to a set of loggers and, optionally, a broadcast bot.
+// The package-level Log uses the default logger, which is configured with
+// SetLogTypes and NewBroadcastBot. Separate loggers let one process report
+// several logical services, each through its own bot.
+type Logger struct {
+	service  string
+	logTypes []LogType // Nil uses the loggers configured with SetLogTypes.
+
+	mu  sync.RWMutex
+	bot *BroadcastBot
+}
+
+// LoggerParams configures a Logger created with NewLogger.
+type LoggerParams struct {
+	ServiceName string        // Service name of the entries. Empty uses the name set by NewBroadcastBot.
+	LogTypes    []LogType     // Loggers to write to. Empty uses the loggers configured with SetLogTypes.
+	Bot         *BroadcastBot // Bot the entries are sent to, if any.
+}
+
+// defaultLogger is the logger used by the package-level functions.
+var defaultLogger = &Logger{}
+
+// NewLogger creates a logger with its own service name, loggers and bot.
+func NewLogger(params LoggerParams) *Logger {
+	l := &Logger{service: params.ServiceName, bot: params.Bot}
+	if len(params.LogTypes) > 0 {
+		l.logTypes = useLogTypes(params.LogTypes...)
+	}
+	return l
+}
+
+// Default returns the logger used by the package-level functions such as Log.
+func Default() *Logger {
+	return defaultLogger
+}
+
+// SetBot attaches a bot to the logger, replacing the previous one, which is returned.
+// Nil detaches the bot. Entries already being delivered finish with the bot they started with;
+// the caller may Close the previous bot once it is no longer attached anywhere.
+func (l *Logger) SetBot(b *BroadcastBot) *BroadcastBot {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 
-	return slog.New(slog.NewJSONHandler(mw, &slog.HandlerOptions{
+	old := l.bot
+	l.bot = b
+	return old
+}
+
+// Bot returns the bot attached to the logger, or nil.
+func (l *Logger) Bot() *BroadcastBot {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.bot
+}
+
+// serviceName returns the service name of the entries logged by the logger.
+func (l *Logger) serviceName() string {
+	if l.service != "" {
+		return l.service
+	}
+	return currentSettings().service
+}
+
+// sinks returns the loggers the logger writes to, with the current level and log file.
+func (l *Logger) sinks() []logSink {
+	s := currentSettings()
+	if l.logTypes != nil {
+		return s.sinksFor(l.logTypes)
+	}
+	return s.sinks
+}
+
+// newJSONLogger creates a new JSON logger for structured logging with no source path.
+func newJSONLogger(output io.Writer, lvl slog.Level) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(output, &slog.HandlerOptions{
 		AddSource: false, // Disable source file and line number information.
-		Level:     slog.LevelError,
+		Level:     lvl,
 	}))
 }
 
 // newTextLogger creates a new text logger for human-readable logging with no source path.
-func newTextLogger(output io.Writer) *slog.Logger {
-	return slog.New(newPrettyHandler(output, slog.LevelError))
+func newTextLogger(output io.Writer, lvl slog.Level) *slog.Logger {
+	return slog.New(newPrettyHandler(output, lvl))
 }
 
 // newPrettyHandler creates a custom pretty handler for formatted logging.
@@ -41,10 +110,10 @@ func newPrettyHandler(output io.Writer, lvl slog.Level) slog.Handler {
 }
 
 // newFileLogger creates a logger that writes logs to a specified file without source paths.
-func newFileLogger(file *os.File) *slog.Logger {
+func newFileLogger(file io.Writer, lvl slog.Level) *slog.Logger {
 	return slog.New(slog.NewJSONHandler(file, &slog.HandlerOptions{
 		AddSource: false, // Disable source file and line number information for file logging.
-		Level:     slog.LevelError,
+		Level:     lvl,
 	}))
 }
 
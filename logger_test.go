@@ -3,12 +3,14 @@ package errs
 import (
 	"bytes"
 	"log/slog"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewJSONLogger(t *testing.T) {
 	var buf bytes.Buffer
-	logger := newJSONLogger(&buf)
+	logger := newJSONLogger(&buf, slog.LevelError)
 
 	logger.Error("Test error", slog.String("key", "value"))
 
@@ -19,7 +21,7 @@ func TestNewJSONLogger(t *testing.T) {
 
 func TestNewTextLogger(t *testing.T) {
 	var buf bytes.Buffer
-	logger := newTextLogger(&buf)
+	logger := newTextLogger(&buf, slog.LevelError)
 
 	logger.Error("Test text error", slog.String("key", "value"))
 
@@ -27,3 +29,75 @@ func TestNewTextLogger(t *testing.T) {
 		t.Fatal("Expected logs to be written")
 	}
 }
+
+func TestLogger_MultipleBots(t *testing.T) {
+	billingAPI, authAPI := newFakeBotAPI(t), newFakeBotAPI(t)
+	billingBot := newTestBot(t, billingAPI, BroadcastBotParams{ServiceName: "billing", ChatIDs: []int64{1}})
+	authBot := newTestBot(t, authAPI, BroadcastBotParams{ServiceName: "auth", ChatIDs: []int64{2}})
+
+	billing := NewLogger(LoggerParams{ServiceName: "billing", Bot: billingBot})
+	auth := NewLogger(LoggerParams{ServiceName: "auth", Bot: authBot})
+
+	billing.Log(New("card declined"), nil)
+	auth.Log(New("token expired"), nil)
+
+	billingMsg := billingAPI.waitCalls(t, "sendMessage", 1)[0].Params
+	authMsg := authAPI.waitCalls(t, "sendMessage", 1)[0].Params
+
+	if billingMsg.Get("chat_id") != "1" || !strings.Contains(billingMsg.Get("text"), "card declined") {
+		t.Fatalf("Unexpected billing message %v", billingMsg)
+	}
+	if authMsg.Get("chat_id") != "2" || !strings.Contains(authMsg.Get("text"), "token expired") {
+		t.Fatalf("Unexpected auth message %v", authMsg)
+	}
+}
+
+func TestLogger_SetBot(t *testing.T) {
+	oldAPI, newAPI := newFakeBotAPI(t), newFakeBotAPI(t)
+	l := NewLogger(LoggerParams{Bot: newTestBot(t, oldAPI, BroadcastBotParams{ChatIDs: []int64{1}})})
+
+	old := l.SetBot(newTestBot(t, newAPI, BroadcastBotParams{ChatIDs: []int64{1}}))
+	old.Close()
+
+	l.getLogger(Entry{Time: time.Now(), Level: slog.LevelError})
+	if len(oldAPI.calls("sendMessage")) != 0 || len(newAPI.calls("sendMessage")) != 1 {
+		t.Fatal("Expected entries to be sent through the new bot")
+	}
+
+	l.SetBot(nil)
+	l.getLogger(Entry{Time: time.Now(), Level: slog.LevelError})
+	if len(newAPI.calls("sendMessage")) != 1 {
+		t.Fatal("Expected no messages without a bot")
+	}
+}
+
+func TestRoute_Bot(t *testing.T) {
+	generalAPI, paymentsAPI := newFakeBotAPI(t), newFakeBotAPI(t)
+	payments := newTestBot(t, paymentsAPI, BroadcastBotParams{ChatIDs: []int64{2}})
+	general := newTestBot(t, generalAPI, BroadcastBotParams{
+		ChatIDs: []int64{1},
+		Routes:  []Route{{Codes: []string{"payment"}, Bot: payments}},
+	})
+
+	l := NewLogger(LoggerParams{Bot: general})
+	l.getLogger(Entry{Time: time.Now(), Level: slog.LevelError, Code: "payment"})
+	l.getLogger(Entry{Time: time.Now(), Level: slog.LevelError, Code: "other"})
+
+	if len(paymentsAPI.calls("sendMessage")) != 1 || len(generalAPI.calls("sendMessage")) != 1 {
+		t.Fatal("Expected the matched entry to be sent through the route bot")
+	}
+}
+
+// newTestBot creates a lazy bot against the fake server that is closed when the test ends.
+func newTestBot(t *testing.T, f *fakeBotAPI, params BroadcastBotParams) *BroadcastBot {
+	params.Token = fakeBotToken
+	params.APIEndpoint = f.URL
+	params.Lazy = true
+
+	b, err := NewBot(params)
+	if err != nil {
+		t.Fatal("Expected no error, got", err)
+	}
+	t.Cleanup(b.Close)
+	return b
+}
@@ -0,0 +1,97 @@
+package errs
+
+import (
+	"errors"
+	"strings"
+
+	botV5 "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ParseMode selects the Telegram formatting used for bot messages.
+type ParseMode string
+
+const (
+	ParseModeMarkdown   ParseMode = "Markdown"   // Legacy Telegram Markdown (default).
+	ParseModeMarkdownV2 ParseMode = "MarkdownV2" // Telegram MarkdownV2.
+	ParseModeHTML       ParseMode = "HTML"       // Telegram HTML.
+	ParseModeText       ParseMode = "TEXT"       // Plain text without any formatting.
+)
+
+// Replacers for text and for the contents of code blocks; Telegram escapes in code blocks
+// differ from those of the text around them. Unlike botV5.EscapeText, the MarkdownV2 text
+// replacer also escapes the backslash, which would otherwise escape the next character.
+var (
+	markdownV2Replacer = strings.NewReplacer(
+		"\\", "\\\\", "_", "\\_", "*", "\\*", "[", "\\[", "]", "\\]", "(", "\\(", ")", "\\)",
+		"~", "\\~", "`", "\\`", ">", "\\>", "#", "\\#", "+", "\\+", "-", "\\-", "=", "\\=",
+		"|", "\\|", "{", "\\{", "}", "\\}", ".", "\\.", "!", "\\!",
+	)
+	markdownV2CodeReplacer = strings.NewReplacer("\\", "\\\\", "`", "\\`")
+	htmlReplacer           = strings.NewReplacer("<", "&lt;", ">", "&gt;", "&", "&amp;")
+)
+
+// telegram returns the value of the parse_mode parameter for the parse mode.
+// The plain text mode is sent without a parse_mode.
+func (m ParseMode) telegram() string {
+	switch m {
+	case ParseModeText:
+		return ""
+	case "":
+		return string(ParseModeMarkdown)
+	default:
+		return string(m)
+	}
+}
+
+// escape escapes text so that it is displayed literally in the parse mode.
+func (m ParseMode) escape(text string) string {
+	switch m.telegram() {
+	case "":
+		return text
+	case botV5.ModeHTML:
+		return htmlReplacer.Replace(text)
+	case botV5.ModeMarkdownV2:
+		return markdownV2Replacer.Replace(text)
+	default:
+		return botV5.EscapeText(m.telegram(), text)
+	}
+}
+
+// codeBlock renders code as a JSON code block in the parse mode.
+//
+// Legacy Markdown offers no way to escape a code block; messages it cannot
+// parse are resent as plain text by the bot.
+func (m ParseMode) codeBlock(code string) string {
+	switch m.telegram() {
+	case "":
+		return code + "\n"
+	case botV5.ModeHTML:
+		return `<pre><code class="language-json">` + htmlReplacer.Replace(code) + "</code></pre>"
+	case botV5.ModeMarkdownV2:
+		return "```json\n" + markdownV2CodeReplacer.Replace(code) + "\n```"
+	default:
+		return "```json\n" + code + "\n```"
+	}
+}
+
+// codeLength returns the length of code once escaped for a code block in the parse mode.
+func (m ParseMode) codeLength(code string) int {
+	switch m.telegram() {
+	case botV5.ModeHTML:
+		return textLength(htmlReplacer.Replace(code))
+	case botV5.ModeMarkdownV2:
+		return textLength(markdownV2CodeReplacer.Replace(code))
+	default:
+		return textLength(code)
+	}
+}
+
+// isParseError reports whether Telegram rejected a message because of its formatting.
+func isParseError(err error) bool {
+	var tgErr *botV5.Error
+	if !errors.As(err, &tgErr) {
+		return false
+	}
+
+	return strings.Contains(tgErr.Message, "can't parse entities")
+}
@@ -0,0 +1,41 @@
+package errs
+
+import (
+	"testing"
+
+	botV5 "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestLogMessageRender(t *testing.T) {
+	msg := logMessage{
+		header: "Service Name: my_service *1* C:\\logs\\\n",
+		body:   `{"msg": "a <b> & ` + "`c`" + ` \ d"}`,
+	}
+
+	tests := []struct {
+		mode ParseMode
+		want string
+	}{
+		{"", "Service Name: my\\_service \\*1\\* C:\\logs\\\n```json\n" + msg.body + "\n```"},
+		{ParseModeMarkdownV2, "Service Name: my\\_service \\*1\\* C:\\\\logs\\\\\n```json\n" + `{"msg": "a <b> & \` + "`c\\`" + ` \\ d"}` + "\n```"},
+		{ParseModeHTML, "Service Name: my_service *1* C:\\logs\\\n" + `<pre><code class="language-json">{"msg": "a &lt;b&gt; &amp; ` + "`c`" + ` \ d"}</code></pre>`},
+		{ParseModeText, msg.header + msg.body + "\n"},
+	}
+
+	for _, tt := range tests {
+		if got := msg.render(tt.mode); got != tt.want {
+			t.Errorf("render(%q) = %q, want %q", tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestIsParseError(t *testing.T) {
+	err := &botV5.Error{Code: 400, Message: "Bad Request: can't parse entities: Can't find end of the entity"}
+	if !isParseError(err) {
+		t.Fatal("Expected a formatting error to be detected")
+	}
+
+	if isParseError(New("can't parse entities")) {
+		t.Fatal("Expected only Telegram errors to be treated as formatting errors")
+	}
+}
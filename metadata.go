@@ -0,0 +1,136 @@
+package errs
+
+import (
+	"os"
+	"runtime/debug"
+	"sync"
+)
+
+// Metadata identifies the process that logged an entry. It is attached to every entry
+// logged with Log, so that errors of different replicas and deployments can be told apart.
+// Empty fields are omitted from the logs.
+type Metadata struct {
+	Host        string            `json:"host,omitempty"`        // Hostname of the machine.
+	PID         int               `json:"pid,omitempty"`         // Process ID.
+	Environment string            `json:"environment,omitempty"` // Deployment environment, such as "production".
+	Version     string            `json:"version,omitempty"`     // Version of the main module.
+	Revision    string            `json:"revision,omitempty"`    // VCS revision the binary was built from.
+	GoVersion   string            `json:"go_version,omitempty"`  // Go version the binary was built with.
+	Pod         string            `json:"pod,omitempty"`         // Kubernetes pod name.
+	Namespace   string            `json:"namespace,omitempty"`   // Kubernetes namespace.
+	Node        string            `json:"node,omitempty"`        // Kubernetes node name.
+	Attrs       map[string]string `json:"attrs,omitempty"`       // Static user-supplied attributes.
+}
+
+// Environment variables DetectMetadata reads. The Kubernetes variables are usually
+// set from the downward API in the pod spec.
+const (
+	envEnvironment = "APP_ENV"
+	envPod         = "POD_NAME"
+	envNamespace   = "POD_NAMESPACE"
+	envNode        = "NODE_NAME"
+)
+
+// metadata holds the metadata attached to new entries.
+var metadata = struct {
+	sync.RWMutex
+	m Metadata
+}{m: DetectMetadata()}
+
+// DetectMetadata collects the metadata of the current process: the hostname and pid,
+// the environment from APP_ENV, the version and VCS revision from the build info, and
+// the Kubernetes pod, namespace and node from POD_NAME, POD_NAMESPACE and NODE_NAME.
+//
+// Returns:
+//   - The detected metadata. Values that cannot be detected are left empty.
+func DetectMetadata() Metadata {
+	host, _ := os.Hostname()
+	m := Metadata{
+		Host:        host,
+		PID:         os.Getpid(),
+		Environment: os.Getenv(envEnvironment),
+		Pod:         os.Getenv(envPod),
+		Namespace:   os.Getenv(envNamespace),
+		Node:        os.Getenv(envNode),
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		m.GoVersion = info.GoVersion
+		if info.Main.Version != "(devel)" {
+			m.Version = info.Main.Version
+		}
+
+		modified := false
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				m.Revision = s.Value
+			case "vcs.modified":
+				modified = s.Value == "true"
+			}
+		}
+		if m.Revision != "" && modified {
+			m.Revision += "-dirty"
+		}
+	}
+
+	return m
+}
+
+// SetMetadata replaces the metadata attached to new entries, for example to set the
+// environment and version explicitly or to add static attributes:
+//
+//	m := errs.DetectMetadata()
+//	m.Environment = "staging"
+//	m.Attrs = map[string]string{"region": "eu-west-1"}
+//	errs.SetMetadata(m)
+//
+// Parameters:
+//   - m: The metadata to attach. A zero Metadata attaches nothing.
+func SetMetadata(m Metadata) {
+	m.Attrs = cloneAttrs(m.Attrs)
+
+	metadata.Lock()
+	defer metadata.Unlock()
+
+	metadata.m = m
+}
+
+// GetMetadata returns the metadata attached to new entries.
+func GetMetadata() Metadata {
+	metadata.RLock()
+	defer metadata.RUnlock()
+
+	m := metadata.m
+	m.Attrs = cloneAttrs(m.Attrs)
+	return m
+}
+
+// cloneAttrs copies static attributes so that callers cannot modify the attached ones.
+func cloneAttrs(attrs map[string]string) map[string]string {
+	if len(attrs) == 0 {
+		return nil
+	}
+
+	clone := make(map[string]string, len(attrs))
+	for key, value := range attrs {
+		clone[key] = value
+	}
+	return clone
+}
+
+// instance returns the name of the replica that logged an entry: the pod name
+// when running in Kubernetes, otherwise the hostname.
+func (m Metadata) instance() string {
+	if m.Pod != "" {
+		return m.Pod
+	}
+	return m.Host
+}
+
+// isZero reports whether the metadata has no values.
+func (m Metadata) isZero() bool {
+	return m.Host == "" && m.PID == 0 && m.Environment == "" && m.Version == "" &&
+		m.Revision == "" && m.GoVersion == "" && m.Pod == "" && m.Namespace == "" &&
+		m.Node == "" && len(m.Attrs) == 0
+}
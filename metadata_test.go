@@ -0,0 +1,97 @@
+package errs
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetectMetadata(t *testing.T) {
+	t.Setenv(envEnvironment, "staging")
+	t.Setenv(envPod, "api-7d9f")
+	t.Setenv(envNamespace, "billing")
+	t.Setenv(envNode, "node-1")
+
+	m := DetectMetadata()
+	if host, _ := os.Hostname(); m.Host != host {
+		t.Errorf("Expected host %q, got %q", host, m.Host)
+	}
+	if m.PID != os.Getpid() {
+		t.Errorf("Expected pid %d, got %d", os.Getpid(), m.PID)
+	}
+	if m.Environment != "staging" || m.Pod != "api-7d9f" || m.Namespace != "billing" || m.Node != "node-1" {
+		t.Errorf("Expected the metadata to be read from the environment, got %+v", m)
+	}
+	if m.GoVersion == "" {
+		t.Error("Expected the Go version from the build info")
+	}
+	if m.instance() != "api-7d9f" {
+		t.Errorf("Expected the pod to name the instance, got %q", m.instance())
+	}
+}
+
+func TestSetMetadata(t *testing.T) {
+	old := GetMetadata()
+	t.Cleanup(func() { SetMetadata(old) })
+
+	attrs := map[string]string{"region": "eu-west-1"}
+	SetMetadata(Metadata{Host: "host-1", Environment: "production", Attrs: attrs})
+	attrs["region"] = "changed"
+
+	m := GetMetadata()
+	if m.Host != "host-1" || m.Environment != "production" || m.Attrs["region"] != "eu-west-1" {
+		t.Fatalf("Expected the metadata to be replaced, got %+v", m)
+	}
+
+	m.Attrs["region"] = "changed"
+	if GetMetadata().Attrs["region"] != "eu-west-1" {
+		t.Fatal("Expected the attached attributes not to be modifiable")
+	}
+}
+
+func TestEntry_JSON_Metadata(t *testing.T) {
+	e := Entry{Metadata: Metadata{Host: "host-1", PID: 42, Attrs: map[string]string{"region": "eu"}}}
+
+	var got struct {
+		Metadata map[string]any `json:"metadata"`
+	}
+	if err := json.Unmarshal([]byte(e.json()), &got); err != nil {
+		t.Fatal("Expected valid JSON, got", err)
+	}
+
+	if got.Metadata["host"] != "host-1" || got.Metadata["pid"] != float64(42) {
+		t.Fatalf("Expected the metadata to be logged, got %v", got.Metadata)
+	}
+	if _, ok := got.Metadata["environment"]; ok {
+		t.Error("Expected empty metadata values to be omitted")
+	}
+	if attrs, _ := got.Metadata["attrs"].(map[string]any); attrs["region"] != "eu" {
+		t.Errorf("Expected the static attributes to be logged, got %v", got.Metadata["attrs"])
+	}
+
+	if strings.Contains(Entry{}.json(), "metadata") {
+		t.Error("Expected no metadata field without metadata")
+	}
+}
+
+func TestBroadcastBot_MetadataHeader(t *testing.T) {
+	f := newFakeBotAPI(t)
+	setupFakeBot(t, f, BroadcastBotParams{ChatIDs: []int64{1}, ParseMode: ParseModeText, Lazy: true})
+
+	Default().getLogger(Entry{
+		Time:     time.Now(),
+		Level:    slog.LevelError,
+		Metadata: Metadata{Host: "host-1", Pod: "api-7d9f", Environment: "production"},
+	})
+
+	sent := f.calls("sendMessage")
+	if len(sent) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(sent))
+	}
+	if text := sent[0].Params.Get("text"); !strings.HasPrefix(text, "Service Name: test_service\nHost: api-7d9f\nEnvironment: production\n") {
+		t.Fatalf("Expected the header to name the instance, got %q", text)
+	}
+}
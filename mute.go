@@ -0,0 +1,63 @@
+package errs
+
+import (
+	"sync"
+	"time"
+)
+
+// mutes holds the muted fingerprints and the time their mute expires.
+var mutes = struct {
+	sync.Mutex
+	until map[string]time.Time
+}{until: map[string]time.Time{}}
+
+// Mute suppresses entries with the given fingerprint in all loggers and the broadcast bot
+// for the given duration. Muting an already muted fingerprint replaces its expiry.
+//
+// Parameters:
+//   - fingerprint: The fingerprint of the error to mute, as shown in bot messages.
+//   - d: How long the fingerprint stays muted.
+func Mute(fingerprint string, d time.Duration) {
+	mutes.Lock()
+	defer mutes.Unlock()
+
+	mutes.until[fingerprint] = time.Now().Add(d)
+}
+
+// Unmute lifts the mute of the given fingerprint. It does nothing if the fingerprint is not muted.
+func Unmute(fingerprint string) {
+	mutes.Lock()
+	defer mutes.Unlock()
+
+	delete(mutes.until, fingerprint)
+}
+
+// IsMuted reports whether entries with the given fingerprint are currently muted.
+func IsMuted(fingerprint string) bool {
+	mutes.Lock()
+	defer mutes.Unlock()
+
+	until, ok := mutes.until[fingerprint]
+	if ok && time.Now().After(until) {
+		delete(mutes.until, fingerprint)
+		return false
+	}
+	return ok
+}
+
+// Mutes returns the currently muted fingerprints and the time each mute expires.
+func Mutes() map[string]time.Time {
+	mutes.Lock()
+	defer mutes.Unlock()
+
+	now := time.Now()
+	active := make(map[string]time.Time, len(mutes.until))
+	for fp, until := range mutes.until {
+		if now.After(until) {
+			delete(mutes.until, fp)
+			continue
+		}
+		active[fp] = until
+	}
+	return active
+}
@@ -0,0 +1,45 @@
+package errs
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestMute(t *testing.T) {
+	Mute("fp_test", time.Hour)
+	if !IsMuted("fp_test") {
+		t.Fatal("Expected the fingerprint to be muted")
+	}
+	if _, ok := Mutes()["fp_test"]; !ok {
+		t.Fatal("Expected the mute to be listed")
+	}
+
+	Unmute("fp_test")
+	if IsMuted("fp_test") {
+		t.Fatal("Expected the fingerprint to be unmuted")
+	}
+}
+
+func TestMute_Expired(t *testing.T) {
+	Mute("fp_expired", -time.Second)
+	if IsMuted("fp_expired") {
+		t.Fatal("Expected an expired mute to be ignored")
+	}
+	if _, ok := Mutes()["fp_expired"]; ok {
+		t.Fatal("Expected an expired mute not to be listed")
+	}
+}
+
+func TestMute_SuppressesDelivery(t *testing.T) {
+	f := newFakeBotAPI(t)
+	setupFakeBot(t, f, BroadcastBotParams{ChatIDs: []int64{1}, Lazy: true})
+
+	Mute("fp_muted", time.Hour)
+	t.Cleanup(func() { Unmute("fp_muted") })
+
+	Default().getLogger(Entry{Time: time.Now(), Level: slog.LevelError, Fingerprint: "fp_muted"})
+	if len(f.calls("sendMessage")) != 0 {
+		t.Fatal("Expected a muted entry not to be sent")
+	}
+}
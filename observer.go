@@ -0,0 +1,153 @@
+package errs
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// ContextExtractor returns attributes of a context to add as fields to every entry logged
+// with LogCtx, for example the trace and span IDs of the active span.
+type ContextExtractor func(ctx context.Context) []slog.Attr
+
+// LogObserver is notified of every error logged with Log, LogID, LogCtx or LogWith before
+// the entry is delivered, including entries that are muted, but not entries dropped by a
+// BeforeLogHook. Observers run on the goroutine delivering the entry and must not block.
+type LogObserver func(ctx context.Context, err error, e Entry)
+
+// DeliveryOutcome is the outcome of delivering an entry to a sink.
+type DeliveryOutcome string
+
+const (
+	DeliverySuccess DeliveryOutcome = "success" // The sink received the entry.
+	DeliveryFailure DeliveryOutcome = "failure" // The sink failed to receive the entry.
+	DeliveryDropped DeliveryOutcome = "dropped" // The throttle of the sink suppressed the entry.
+	DeliveryRetried DeliveryOutcome = "retried" // The sink rejected the entry and it is sent again, for example as plain text.
+)
+
+// DeliveryObserver is notified of the outcome of every delivery to a sink. Sinks are named
+// like in GetStatus. Observers run on the goroutine delivering the entry and must not block.
+type DeliveryObserver func(sink string, outcome DeliveryOutcome)
+
+// hooks holds the registered context extractors, observers, hooks and filters.
+var hooks struct {
+	sync.RWMutex
+	extractors []*ContextExtractor
+	observers  []*LogObserver
+	deliveries []*DeliveryObserver
+	beforeLog  []*BeforeLogHook
+	filters    []*sinkFilter
+}
+
+// AddContextExtractor registers a function adding attributes of the context to every entry
+// logged with LogCtx. Attributes of WithAttrs replace extracted attributes with the same key.
+//
+// Parameters:
+//   - fn: The extractor to register.
+//
+// Returns:
+//   - A function that unregisters the extractor.
+func AddContextExtractor(fn ContextExtractor) (remove func()) {
+	hooks.Lock()
+	defer hooks.Unlock()
+
+	p := &fn
+	hooks.extractors = append(hooks.extractors, p)
+	return func() {
+		hooks.Lock()
+		defer hooks.Unlock()
+
+		hooks.extractors = removeHook(hooks.extractors, p)
+	}
+}
+
+// AddLogObserver registers a function that is notified of every logged error,
+// for example to record it on a span or count it in metrics.
+//
+// Parameters:
+//   - fn: The observer to register.
+//
+// Returns:
+//   - A function that unregisters the observer.
+func AddLogObserver(fn LogObserver) (remove func()) {
+	hooks.Lock()
+	defer hooks.Unlock()
+
+	p := &fn
+	hooks.observers = append(hooks.observers, p)
+	return func() {
+		hooks.Lock()
+		defer hooks.Unlock()
+
+		hooks.observers = removeHook(hooks.observers, p)
+	}
+}
+
+// AddDeliveryObserver registers a function that is notified of the outcome of every
+// delivery to a sink, for example to count deliveries in metrics.
+//
+// Parameters:
+//   - fn: The observer to register.
+//
+// Returns:
+//   - A function that unregisters the observer.
+func AddDeliveryObserver(fn DeliveryObserver) (remove func()) {
+	hooks.Lock()
+	defer hooks.Unlock()
+
+	p := &fn
+	hooks.deliveries = append(hooks.deliveries, p)
+	return func() {
+		hooks.Lock()
+		defer hooks.Unlock()
+
+		hooks.deliveries = removeHook(hooks.deliveries, p)
+	}
+}
+
+// extractedAttrs returns the attributes the registered extractors find in ctx.
+func extractedAttrs(ctx context.Context) []slog.Attr {
+	hooks.RLock()
+	extractors := hooks.extractors
+	hooks.RUnlock()
+
+	var attrs []slog.Attr
+	for _, fn := range extractors {
+		attrs = append(attrs, (*fn)(ctx)...)
+	}
+	return attrs
+}
+
+// observe notifies the registered observers of a logged error.
+func observe(ctx context.Context, err error, e Entry) {
+	hooks.RLock()
+	observers := hooks.observers
+	hooks.RUnlock()
+
+	for _, fn := range observers {
+		(*fn)(ctx, err, e)
+	}
+}
+
+// observeDelivery notifies the registered observers of the outcome of a delivery.
+func observeDelivery(sink string, outcome DeliveryOutcome) {
+	hooks.RLock()
+	observers := hooks.deliveries
+	hooks.RUnlock()
+
+	for _, fn := range observers {
+		(*fn)(sink, outcome)
+	}
+}
+
+// removeHook returns hooks without h. It copies the slice, so that callers iterating over
+// the previous slice are not affected.
+func removeHook[T any](hooks []*T, h *T) []*T {
+	kept := make([]*T, 0, len(hooks))
+	for _, hook := range hooks {
+		if hook != h {
+			kept = append(kept, hook)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,65 @@
+package errs
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+)
+
+func TestHooks(t *testing.T) {
+	removeExtractor := AddContextExtractor(func(ctx context.Context) []slog.Attr {
+		return []slog.Attr{slog.String("trace_id", "t-1"), slog.String("tenant", "extracted")}
+	})
+
+	observed := make(chan Entry, 1)
+	removeObserver := AddLogObserver(func(_ context.Context, err error, e Entry) {
+		if err != nil {
+			observed <- e
+		}
+	})
+
+	LogWith(New("failed"), Ctx(WithAttrs(context.Background(), "tenant", "acme")), Sync())
+
+	e := <-observed
+	if e.Fields["trace_id"] != "t-1" || e.Fields["tenant"] != "acme" {
+		t.Fatalf("Expected extracted attributes below WithAttrs ones, got %v", e.Fields)
+	}
+
+	removeExtractor()
+	removeObserver()
+	LogWith(New("failed"), Sync())
+
+	select {
+	case e := <-observed:
+		t.Fatalf("Expected the observer to be removed, got %+v", e)
+	default:
+	}
+	if len(extractedAttrs(context.Background())) != 0 {
+		t.Fatal("Expected the extractor to be removed")
+	}
+}
+
+func TestAddDeliveryObserver(t *testing.T) {
+	var outcomes []DeliveryOutcome
+	remove := AddDeliveryObserver(func(sink string, outcome DeliveryOutcome) {
+		if sink == "TEST_OBSERVER" {
+			outcomes = append(outcomes, outcome)
+		}
+	})
+	defer remove()
+
+	deliverThrottled("TEST_OBSERVER", Entry{}, func(Entry) error { return nil })
+	deliverThrottled("TEST_OBSERVER", Entry{}, func(Entry) error { return New("failed") })
+	recordSuppressed("TEST_OBSERVER")
+	recordRetry("TEST_OBSERVER")
+
+	want := []DeliveryOutcome{DeliverySuccess, DeliveryFailure, DeliveryDropped, DeliveryRetried}
+	if len(outcomes) != len(want) {
+		t.Fatalf("Expected outcomes %v, got %v", want, outcomes)
+	}
+	for i := range want {
+		if outcomes[i] != want[i] {
+			t.Fatalf("Expected outcomes %v, got %v", want, outcomes)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package errs
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// LogOption configures a single call of LogWith.
+type LogOption func(*logOptions)
+
+// logOptions holds the options of a LogWith call.
+type logOptions struct {
+	ctx      context.Context
+	req      any
+	msgs     []any
+	level    *slog.Level
+	noNotify bool
+	fields   []slog.Attr
+	only     []string
+	sync     bool
+}
+
+// Ctx delivers the entry with a context, like LogCtx: its attributes are logged with
+// the entry, see WithAttrs. With Sync, sending it to Telegram is cancelled when it is done.
+func Ctx(ctx context.Context) LogOption {
+	return func(o *logOptions) {
+		if ctx != nil {
+			o.ctx = ctx
+		}
+	}
+}
+
+// Req logs a request object with the entry, like the req argument of Log.
+func Req(req any) LogOption {
+	return func(o *logOptions) { o.req = req }
+}
+
+// Msg adds context messages to the entry, like the msgs arguments of Log.
+// Messages of several Msg options are joined in order.
+func Msg(msgs ...any) LogOption {
+	return func(o *logOptions) { o.msgs = append(o.msgs, msgs...) }
+}
+
+// Level logs the entry at a level instead of the one given by the policy, see SetPolicy.
+// The loggers only write it at or above their level, see SetLevel. Whether the entry is
+// alerted is still decided by the policy; use NoNotify to skip the bots.
+func Level(level slog.Level) LogOption {
+	return func(o *logOptions) { o.level = &level }
+}
+
+// NoNotify keeps the entry from the broadcast bots. It is still written to the loggers.
+func NoNotify() LogOption {
+	return func(o *logOptions) { o.noNotify = true }
+}
+
+// Fields adds fields to the entry, given as alternating keys and values or slog.Attr
+// values like WithAttrs. They take precedence over attributes of the error and the context.
+func Fields(args ...any) LogOption {
+	return func(o *logOptions) { o.fields = append(o.fields, argsToAttrs(args)...) }
+}
+
+// Only delivers the entry to the named sinks only: the loggers by their LogType, such as
+// "file", and the broadcast bots by BotSinkName. Names are case-insensitive; without names
+// the entry is delivered nowhere. The entry is still counted and kept in memory.
+func Only(sinks ...string) LogOption {
+	return func(o *logOptions) { o.only = append(append([]string{}, o.only...), sinks...) }
+}
+
+// Sync makes LogWith return once the entry is delivered to every sink instead of
+// delivering it in the background. Sending the entry to Telegram is then cancelled
+// when the context of Ctx is done.
+func Sync() LogOption {
+	return func(o *logOptions) { o.sync = true }
+}
+
+// LogWith logs an error like LogID, configured by options instead of positional arguments.
+// Log(err, req, msgs...) is the same as LogWith(err, Req(req), Msg(msgs...)).
+//
+// Parameters:
+//   - err: The error to log. If this is nil, the function returns without doing anything.
+//   - opts: Options of the call, such as Req, Msg, Level, NoNotify, Fields, Only and Sync.
+//
+// Returns:
+//   - The ID of the entry. If the provided error is nil, it returns an empty string.
+func LogWith(err error, opts ...LogOption) string {
+	return defaultLogger.LogWith(err, opts...)
+}
+
+// LogWith logs an error like the package-level LogWith, using the loggers,
+// the service name and the bot of l.
+func (l *Logger) LogWith(err error, opts ...LogOption) string {
+	if err == nil {
+		return ""
+	}
+
+	o := logOptions{ctx: context.Background()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	now := time.Now()
+	id := newID(now)
+	if o.sync {
+		l.logError(now, id, err, o)
+		return id
+	}
+
+	// Asynchronously handle the error logging to prevent blocking.
+	pending.Add(1)
+	go func() {
+		defer pending.Add(-1)
+		l.logError(now, id, err, o)
+	}()
+	return id
+}
+
+// delivers reports whether the entry goes to the named sink, see Only.
+func (e Entry) delivers(sink string) bool {
+	if e.only == nil {
+		return true
+	}
+	for _, name := range e.only {
+		if strings.EqualFold(name, sink) {
+			return true
+		}
+	}
+	return false
+}
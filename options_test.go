@@ -0,0 +1,93 @@
+package errs
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLogWith(t *testing.T) {
+	observed := make(chan Entry, 1)
+	removeObserver := AddLogObserver(func(_ context.Context, err error, e Entry) {
+		if err != nil {
+			observed <- e
+		}
+	})
+	defer removeObserver()
+
+	id := LogWith(New("failed"),
+		Req("request"), Msg("first"), Msg("second"), Level(slog.LevelWarn),
+		Fields("tenant", "acme"), Ctx(WithAttrs(context.Background(), "tenant", "other", "user", 7)), Sync())
+
+	e := <-observed
+	if e.ID != id || e.Request != "request" || e.Level != slog.LevelWarn {
+		t.Fatalf("Unexpected entry %+v", e)
+	}
+	if want := JoinMsg(currentSettings().separator, "first", "second"); e.Message != want {
+		t.Fatalf("Expected message %q, got %q", want, e.Message)
+	}
+	if e.Fields["tenant"] != "acme" || e.Fields["user"] != int64(7) {
+		t.Fatalf("Expected the fields of the call over those of the context, got %v", e.Fields)
+	}
+
+	if id := LogWith(nil, Sync()); id != "" {
+		t.Fatalf("Expected no ID for a nil error, got %q", id)
+	}
+}
+
+func TestLogWith_Sinks(t *testing.T) {
+	restoreConfig(t)
+	path := filepath.Join(t.TempDir(), "errors.json")
+	c := CurrentConfig()
+	c.LogTypes, c.LogFile, c.Level = []LogType{LogTypeFile}, path, slog.LevelDebug
+	if err := ApplyConfig(c); err != nil {
+		t.Fatal(err)
+	}
+
+	f := newFakeBotAPI(t)
+	setupFakeBot(t, f, BroadcastBotParams{ChatIDs: []int64{1}})
+
+	lines := func() int {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.Count(string(data), "\n")
+	}
+
+	LogWith(New("file only"), Only("file"), Level(slog.LevelInfo), Sync())
+	if n := lines(); n != 1 {
+		t.Fatalf("Expected 1 line in the log file, got %d", n)
+	}
+	if sent := f.calls("sendMessage"); len(sent) != 0 {
+		t.Fatalf("Expected no message for an entry to the file only, got %d", len(sent))
+	}
+
+	LogWith(New("not alerted"), NoNotify(), Sync())
+	if n := lines(); n != 2 {
+		t.Fatalf("Expected 2 lines in the log file, got %d", n)
+	}
+	if sent := f.calls("sendMessage"); len(sent) != 0 {
+		t.Fatalf("Expected no message with NoNotify, got %d", len(sent))
+	}
+
+	LogWith(New("bot only"), Only(BotSinkName), Sync())
+	if n := lines(); n != 2 {
+		t.Fatalf("Expected no new line in the log file, got %d lines", n)
+	}
+	sent := f.calls("sendMessage")
+	if len(sent) != 1 || !strings.Contains(sent[0].Params.Get("text"), "bot only") {
+		t.Fatalf("Expected 1 message for an entry to the bot only, got %v", sent)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"level":"INFO"`) || !strings.Contains(string(data), "file only") {
+		t.Fatalf("Expected the file to honor the level of the call, got %s", data)
+	}
+}
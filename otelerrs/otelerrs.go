@@ -0,0 +1,113 @@
+// Package otelerrs connects errs to OpenTelemetry tracing. It records logged errors on the
+// active span as exception events and adds the trace and span IDs of the span to every
+// entry logged with errs.LogCtx, so that logs and alerts can be looked up in traces.
+//
+//	uninstall := otelerrs.Install()
+//	defer uninstall()
+//
+//	errs.LogCtx(ctx, err, req, "charge failed")
+package otelerrs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/sulton0011/errs"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Field names of the trace and span IDs in entries.
+const (
+	TraceIDKey = "trace_id"
+	SpanIDKey  = "span_id"
+)
+
+// Attribute keys of the errs specific values recorded on exception events.
+const (
+	errorIDKey     = attribute.Key("errs.error_id")
+	fingerprintKey = attribute.Key("errs.fingerprint")
+)
+
+// Install registers TraceAttrs as a context extractor and records every error logged with
+// errs.LogCtx on the span of its context.
+//
+// Returns:
+//   - A function that unregisters both hooks.
+func Install() (uninstall func()) {
+	removeExtractor := errs.AddContextExtractor(TraceAttrs)
+	removeObserver := errs.AddLogObserver(func(ctx context.Context, err error, e errs.Entry) {
+		recordError(ctx, err, errorIDKey.String(e.ID), fingerprintKey.String(e.Fingerprint))
+	})
+
+	return func() {
+		removeExtractor()
+		removeObserver()
+	}
+}
+
+// TraceAttrs returns the trace and span IDs of the span in ctx as the "trace_id" and
+// "span_id" attributes. It returns nil if ctx has no valid span.
+func TraceAttrs(ctx context.Context) []slog.Attr {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+
+	return []slog.Attr{
+		slog.String(TraceIDKey, sc.TraceID().String()),
+		slog.String(SpanIDKey, sc.SpanID().String()),
+	}
+}
+
+// RecordError records an error on the span in ctx as an exception event and sets the status
+// of the span to Error. The event carries the type of the error (its code, if it has one),
+// the message chain of errs.Unwrap and the stack the error was created at.
+// It does nothing if err is nil or the span is not recording.
+//
+// Parameters:
+//   - ctx: The context carrying the span.
+//   - err: The error to record.
+func RecordError(ctx context.Context, err error) {
+	recordError(ctx, err)
+}
+
+// recordError records an error on the span in ctx with additional event attributes.
+func recordError(ctx context.Context, err error, attrs ...attribute.KeyValue) {
+	span := trace.SpanFromContext(ctx)
+	if err == nil || !span.IsRecording() {
+		return
+	}
+
+	attrs = append(attrs,
+		semconv.ExceptionType(errorType(err)),
+		semconv.ExceptionMessage(errs.Unwrap(err)),
+	)
+	if stack := stackTrace(err); stack != "" {
+		attrs = append(attrs, semconv.ExceptionStacktrace(stack))
+	}
+
+	span.AddEvent(semconv.ExceptionEventName, trace.WithAttributes(attrs...))
+	span.SetStatus(codes.Error, errs.Unwrap(err))
+}
+
+// errorType returns the code of an error or, without one, its Go type.
+func errorType(err error) string {
+	if code := errs.Code(err); code != "" {
+		return code
+	}
+	return fmt.Sprintf("%T", err)
+}
+
+// stackTrace formats the stack of an error like the stack of a goroutine in a panic.
+func stackTrace(err error) string {
+	var b strings.Builder
+	for _, frame := range errs.Stack(err) {
+		fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+	}
+	return b.String()
+}
@@ -0,0 +1,106 @@
+package otelerrs
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sulton0011/errs"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+func newTracer(t *testing.T) (*tracetest.SpanRecorder, *sdktrace.TracerProvider) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+	return recorder, tp
+}
+
+func eventAttrs(span sdktrace.ReadOnlySpan) map[attribute.Key]string {
+	attrs := map[attribute.Key]string{}
+	for _, event := range span.Events() {
+		if event.Name != semconv.ExceptionEventName {
+			continue
+		}
+		for _, kv := range event.Attributes {
+			attrs[kv.Key] = kv.Value.Emit()
+		}
+	}
+	return attrs
+}
+
+func TestRecordError(t *testing.T) {
+	recorder, tp := newTracer(t)
+	ctx, span := tp.Tracer("test").Start(context.Background(), "charge")
+
+	RecordError(ctx, errs.Wrap(errs.WithCode(errs.New("card declined"), "payment_declined"), "charge failed"))
+	RecordError(ctx, nil)
+	span.End()
+
+	ended := recorder.Ended()
+	if len(ended) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(ended))
+	}
+	if ended[0].Status().Code != codes.Error || ended[0].Status().Description != "charge failed ---> card declined" {
+		t.Fatalf("Expected the span status to be set, got %+v", ended[0].Status())
+	}
+
+	attrs := eventAttrs(ended[0])
+	if attrs[semconv.ExceptionTypeKey] != "payment_declined" {
+		t.Errorf("Expected the code as the exception type, got %q", attrs[semconv.ExceptionTypeKey])
+	}
+	if attrs[semconv.ExceptionMessageKey] != "charge failed ---> card declined" {
+		t.Errorf("Expected the message chain, got %q", attrs[semconv.ExceptionMessageKey])
+	}
+	if !strings.Contains(attrs[semconv.ExceptionStacktraceKey], "otelerrs.TestRecordError") {
+		t.Errorf("Expected the stack of the error, got %q", attrs[semconv.ExceptionStacktraceKey])
+	}
+}
+
+func TestTraceAttrs(t *testing.T) {
+	if TraceAttrs(context.Background()) != nil {
+		t.Fatal("Expected no attributes without a span")
+	}
+
+	_, tp := newTracer(t)
+	ctx, span := tp.Tracer("test").Start(context.Background(), "charge")
+	defer span.End()
+
+	attrs := TraceAttrs(ctx)
+	sc := span.SpanContext()
+	if len(attrs) != 2 || attrs[0].Value.String() != sc.TraceID().String() || attrs[1].Value.String() != sc.SpanID().String() {
+		t.Fatalf("Expected the trace and span IDs, got %v", attrs)
+	}
+}
+
+func TestInstall(t *testing.T) {
+	recorder, tp := newTracer(t)
+
+	var entries []errs.Entry
+	remove := errs.AddLogObserver(func(_ context.Context, _ error, e errs.Entry) { entries = append(entries, e) })
+	defer remove()
+
+	uninstall := Install()
+	defer uninstall()
+
+	ctx, span := tp.Tracer("test").Start(context.Background(), "charge")
+	id := errs.LogCtx(ctx, errs.New("failed"), nil)
+
+	deadline := time.Now().Add(time.Second)
+	for errs.GetStatus().QueueDepth > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	span.End()
+
+	if len(entries) != 1 || entries[0].Fields[TraceIDKey] != span.SpanContext().TraceID().String() {
+		t.Fatalf("Expected the trace ID in the entry, got %v", entries)
+	}
+	if attrs := eventAttrs(recorder.Ended()[0]); attrs[errorIDKey] != id {
+		t.Fatalf("Expected the error to be recorded on the span, got %v", attrs)
+	}
+}
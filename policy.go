@@ -0,0 +1,88 @@
+package errs
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+)
+
+const (
+	CodeNotFound    = "not_found"   // Code of errors for missing resources, logged at info level by DefaultPolicy.
+	CodeUnavailable = "unavailable" // Code of errors for unavailable dependencies, alerted by DefaultPolicy.
+)
+
+// PolicyRule gives the errors it matches a level and decides whether they are alerted.
+//
+// All conditions that are set must match for the rule to apply; a rule without
+// conditions matches every error. Rules are evaluated in order and the first
+// matching rule wins, see SetPolicy.
+type PolicyRule struct {
+	Codes  []string         // Error codes the rule applies to, see WithCode.
+	Errors []error          // Sentinel errors the rule applies to, also when wrapped, see Is.
+	Match  func(error) bool // Custom condition.
+
+	Level   slog.Level // Level of the entries of the matched errors.
+	NoAlert bool       // Keep the matched errors from the broadcast bots, for expected errors. By default they are alerted.
+}
+
+// SetPolicy sets the rules that give logged errors their level and decide whether they are
+// alerted, so that handlers can call Log without deciding the severity themselves. Errors no
+// rule matches are logged at error level and alerted. Errors that are not alerted are still
+// written to the loggers, counted and kept in memory; the level decides whether the loggers
+// write them, so lower it with SetLevel to see errors a rule demotes below slog.LevelError.
+//
+// Parameters:
+//   - rules: The rules, evaluated in order. No rules remove the policy.
+func SetPolicy(rules ...PolicyRule) {
+	_ = updateSettings(func(s *settings) error {
+		s.policy = slices.Clone(rules)
+		return nil
+	})
+}
+
+// DefaultPolicy returns rules for common errors, to be passed to SetPolicy as they are
+// or together with rules of the application:
+//   - context.Canceled: debug level, not alerted, since the caller went away.
+//   - CodeNotFound: info level, not alerted.
+//   - context.DeadlineExceeded: warning level, alerted.
+//   - CodeUnavailable: error level, alerted.
+func DefaultPolicy() []PolicyRule {
+	return []PolicyRule{
+		{Errors: []error{context.Canceled}, Level: slog.LevelDebug, NoAlert: true},
+		{Codes: []string{CodeNotFound}, Level: slog.LevelInfo, NoAlert: true},
+		{Errors: []error{context.DeadlineExceeded}, Level: slog.LevelWarn},
+		{Codes: []string{CodeUnavailable}, Level: slog.LevelError},
+	}
+}
+
+// Classify applies the policy set with SetPolicy to an error.
+//
+// Parameters:
+//   - err: The error to classify.
+//
+// Returns:
+//   - The level of the error and whether it is alerted: those of the first matching rule,
+//     or slog.LevelError and true if no rule matches.
+func Classify(err error) (level slog.Level, alert bool) {
+	for _, rule := range currentSettings().policy {
+		if rule.matches(err) {
+			return rule.Level, !rule.NoAlert
+		}
+	}
+	return slog.LevelError, true
+}
+
+// matches reports whether the error satisfies every condition of the rule.
+func (r PolicyRule) matches(err error) bool {
+	if len(r.Codes) > 0 && !slices.Contains(r.Codes, Code(err)) {
+		return false
+	}
+	if len(r.Errors) > 0 && !slices.ContainsFunc(r.Errors, func(target error) bool { return errors.Is(err, target) }) {
+		return false
+	}
+	if r.Match != nil && !r.Match(err) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,135 @@
+package errs
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	SetPolicy(DefaultPolicy()...)
+	t.Cleanup(func() { SetPolicy() })
+
+	tests := []struct {
+		name  string
+		err   error
+		level slog.Level
+		alert bool
+	}{
+		{"canceled", Wrap(context.Canceled, "request"), slog.LevelDebug, false},
+		{"not found", Wrap(WithCode(New("no user"), CodeNotFound), "get user"), slog.LevelInfo, false},
+		{"deadline", Join(" && ", New("first"), context.DeadlineExceeded), slog.LevelWarn, true},
+		{"unavailable", WithCode(New("db down"), CodeUnavailable), slog.LevelError, true},
+		{"unmatched", New("failed"), slog.LevelError, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			level, alert := Classify(tt.err)
+			if level != tt.level || alert != tt.alert {
+				t.Fatalf("Expected %v and alert %v, got %v and %v", tt.level, tt.alert, level, alert)
+			}
+		})
+	}
+}
+
+func TestClassify_AllConditions(t *testing.T) {
+	errQuota := errors.New("quota exceeded")
+	SetPolicy(
+		PolicyRule{
+			Codes:   []string{"billing"},
+			Errors:  []error{errQuota},
+			Match:   func(err error) bool { return strings.Contains(Unwrap(err), "tenant") },
+			Level:   slog.LevelWarn,
+			NoAlert: true,
+		},
+		PolicyRule{Level: slog.LevelInfo},
+	)
+	t.Cleanup(func() { SetPolicy() })
+
+	if level, alert := Classify(WithCode(Wrap(errQuota, "tenant acme"), "billing")); level != slog.LevelWarn || alert {
+		t.Fatalf("Expected the first rule to match, got %v and %v", level, alert)
+	}
+	// Missing the code, so only the catch-all rule matches.
+	if level, alert := Classify(Wrap(errQuota, "tenant acme")); level != slog.LevelInfo || !alert {
+		t.Fatalf("Expected the catch-all rule to match, got %v and %v", level, alert)
+	}
+
+	SetPolicy()
+	if level, alert := Classify(errQuota); level != slog.LevelError || !alert {
+		t.Fatalf("Expected the default without a policy, got %v and %v", level, alert)
+	}
+}
+
+func TestLog_Policy(t *testing.T) {
+	f := newFakeBotAPI(t)
+	setupFakeBot(t, f, BroadcastBotParams{ChatIDs: []int64{1}})
+	SetPolicy(DefaultPolicy()...)
+	t.Cleanup(func() { SetPolicy() })
+
+	observed := make(chan Entry, 2)
+	removeObserver := AddLogObserver(func(_ context.Context, err error, e Entry) {
+		if err != nil {
+			observed <- e
+		}
+	})
+	defer removeObserver()
+
+	LogWith(Wrap(context.Canceled, "request"), Sync())
+	if e := <-observed; e.Level != slog.LevelDebug || !e.noAlert {
+		t.Fatalf("Expected a debug entry that is not alerted, got %v and %v", e.Level, e.noAlert)
+	}
+	if sent := f.calls("sendMessage"); len(sent) != 0 {
+		t.Fatalf("Expected no message for an expected error, got %d", len(sent))
+	}
+
+	LogWith(WithCode(New("db down"), CodeUnavailable), Sync())
+	if e := <-observed; e.Level != slog.LevelError || e.noAlert {
+		t.Fatalf("Expected an alerted error entry, got %v and %v", e.Level, e.noAlert)
+	}
+	if sent := f.calls("sendMessage"); len(sent) != 1 {
+		t.Fatalf("Expected 1 message for an alerted error, got %d", len(sent))
+	}
+}
+
+func TestLog_PolicyBelowLevel(t *testing.T) {
+	restoreConfig(t)
+	path := filepath.Join(t.TempDir(), "errors.json")
+	c := CurrentConfig()
+	c.LogTypes, c.LogFile, c.Level = []LogType{LogTypeFile}, path, slog.LevelError
+	if err := ApplyConfig(c); err != nil {
+		t.Fatal(err)
+	}
+	SetPolicy(DefaultPolicy()...)
+	t.Cleanup(func() { SetPolicy() })
+
+	logDemoted := func() {
+		LogWith(Wrap(context.Canceled, "request canceled"), Sync())
+		LogWith(WithCode(New("no user"), CodeNotFound), Sync())
+		LogWith(New("slow request"), Level(slog.LevelWarn), Sync())
+	}
+	read := func() string {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	logDemoted()
+	if data := read(); data != "" {
+		t.Fatalf("Expected the level of the file to skip demoted entries, got %s", data)
+	}
+
+	SetLevel(slog.LevelDebug)
+	logDemoted()
+	data := read()
+	for _, want := range []string{`"level":"DEBUG"`, `"level":"INFO"`, `"level":"WARN"`} {
+		if !strings.Contains(data, want) {
+			t.Errorf("Expected the file to contain %s once the level is lowered, got %s", want, data)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+// Package promerrs exports Prometheus metrics for errs: the logged errors by level, code,
+// service and fingerprint, the outcome of every delivery to a sink, the queue depth and
+// the number of active mutes.
+//
+//	m, err := promerrs.New(prometheus.DefaultRegisterer, promerrs.Options{})
+//	if err != nil {
+//		panic(err)
+//	}
+//	defer m.Close()
+package promerrs
+
+import (
+	"context"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sulton0011/errs"
+)
+
+const (
+	// defaultNamespace is the metric namespace used when Options.Namespace is empty.
+	defaultNamespace = "errs"
+	// defaultMaxFingerprints is the fingerprint limit used when Options.MaxFingerprints is zero.
+	defaultMaxFingerprints = 100
+	// OtherFingerprint is the fingerprint label of errors beyond the fingerprint limit.
+	OtherFingerprint = "other"
+)
+
+// Options configures the metrics created with New.
+type Options struct {
+	// Namespace prefixes the metric names. Empty uses "errs".
+	Namespace string
+	// MaxFingerprints bounds the cardinality of the fingerprint label: errors with a fingerprint
+	// beyond the first MaxFingerprints are counted as "other". Zero means 100, -1 drops the
+	// fingerprint from the label entirely by counting every error as "other".
+	MaxFingerprints int
+}
+
+// Metrics counts logged errors and sink deliveries. Create it with New.
+type Metrics struct {
+	entries    *prometheus.CounterVec
+	deliveries *prometheus.CounterVec
+	collectors []prometheus.Collector
+	registerer prometheus.Registerer
+	remove     []func()
+
+	mu              sync.Mutex
+	fingerprints    map[string]struct{}
+	maxFingerprints int
+}
+
+// New creates the metrics, registers them with reg and starts counting.
+//
+// The metrics are:
+//   - <namespace>_entries_total{level, code, service, fingerprint}: errors logged with errs.Log.
+//   - <namespace>_sink_deliveries_total{sink, outcome}: deliveries to every sink by outcome,
+//     one of success, failure, dropped and retried.
+//   - <namespace>_queue_depth: entries passed to errs.Log that are not delivered yet.
+//   - <namespace>_active_mutes: muted fingerprints.
+//
+// Parameters:
+//   - reg: The registerer to register the metrics with.
+//   - opts: The options of the metrics.
+//
+// Returns:
+//   - The metrics, which stop counting when closed.
+//   - An error if the metrics cannot be registered, for example because they already are.
+func New(reg prometheus.Registerer, opts Options) (*Metrics, error) {
+	namespace := opts.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	maxFingerprints := opts.MaxFingerprints
+	if maxFingerprints == 0 {
+		maxFingerprints = defaultMaxFingerprints
+	}
+
+	m := &Metrics{
+		entries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "entries_total",
+			Help:      "Errors logged, by level, code, service and fingerprint.",
+		}, []string{"level", "code", "service", "fingerprint"}),
+		deliveries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "sink_deliveries_total",
+			Help:      "Deliveries of entries to sinks, by sink and outcome.",
+		}, []string{"sink", "outcome"}),
+		registerer:      reg,
+		fingerprints:    map[string]struct{}{},
+		maxFingerprints: maxFingerprints,
+	}
+	m.collectors = []prometheus.Collector{
+		m.entries,
+		m.deliveries,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "queue_depth",
+			Help:      "Entries passed to Log that are not delivered to every sink yet.",
+		}, func() float64 { return float64(errs.GetStatus().QueueDepth) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "active_mutes",
+			Help:      "Fingerprints that are currently muted.",
+		}, func() float64 { return float64(len(errs.Mutes())) }),
+	}
+
+	for i, c := range m.collectors {
+		if err := reg.Register(c); err != nil {
+			for _, registered := range m.collectors[:i] {
+				reg.Unregister(registered)
+			}
+			return nil, errs.Wrap(err, "failed to register errs metrics")
+		}
+	}
+
+	m.remove = []func(){
+		errs.AddLogObserver(m.observeEntry),
+		errs.AddDeliveryObserver(m.observeDelivery),
+	}
+	return m, nil
+}
+
+// Close stops counting and unregisters the metrics.
+func (m *Metrics) Close() {
+	for _, remove := range m.remove {
+		remove()
+	}
+	for _, c := range m.collectors {
+		m.registerer.Unregister(c)
+	}
+}
+
+// observeEntry counts a logged error.
+func (m *Metrics) observeEntry(_ context.Context, _ error, e errs.Entry) {
+	m.entries.WithLabelValues(e.Level.String(), e.Code, e.Service, m.fingerprint(e.Fingerprint)).Inc()
+}
+
+// observeDelivery counts a delivery to a sink.
+func (m *Metrics) observeDelivery(sink string, outcome errs.DeliveryOutcome) {
+	m.deliveries.WithLabelValues(sink, string(outcome)).Inc()
+}
+
+// fingerprint returns the fingerprint label of an error: the fingerprint itself while fewer
+// than the maximum number of fingerprints were seen, otherwise OtherFingerprint.
+func (m *Metrics) fingerprint(fp string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.fingerprints[fp]; ok {
+		return fp
+	}
+	if len(m.fingerprints) >= m.maxFingerprints {
+		return OtherFingerprint
+	}
+
+	m.fingerprints[fp] = struct{}{}
+	return fp
+}
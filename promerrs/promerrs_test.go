@@ -0,0 +1,91 @@
+package promerrs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sulton0011/errs"
+)
+
+func newMetrics(t *testing.T, opts Options) (*Metrics, *prometheus.Registry) {
+	reg := prometheus.NewRegistry()
+	m, err := New(reg, opts)
+	if err != nil {
+		t.Fatal("Expected no error, got", err)
+	}
+	t.Cleanup(m.Close)
+	return m, reg
+}
+
+func waitDelivered(t *testing.T) {
+	deadline := time.Now().Add(time.Second)
+	for errs.GetStatus().QueueDepth > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the entries to be delivered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	m, reg := newMetrics(t, Options{})
+	logger := errs.NewLogger(errs.LoggerParams{ServiceName: "billing", LogTypes: []errs.LogType{errs.LogTypeJSON}})
+
+	err := errs.WithCode(errs.New("card declined"), "payment_declined")
+	logger.Log(err, nil)
+	logger.Log(err, nil)
+	waitDelivered(t)
+
+	fp := errs.Fingerprint(err)
+	if got := testutil.ToFloat64(m.entries.WithLabelValues("ERROR", "payment_declined", "billing", fp)); got != 2 {
+		t.Fatalf("Expected 2 entries, got %v", got)
+	}
+	if got := testutil.ToFloat64(m.deliveries.WithLabelValues(string(errs.LogTypeJSON), string(errs.DeliverySuccess))); got < 2 {
+		t.Fatalf("Expected 2 successful deliveries, got %v", got)
+	}
+
+	if n := testutil.CollectAndCount(reg, "errs_queue_depth", "errs_active_mutes"); n != 2 {
+		t.Fatalf("Expected the gauges to be registered, got %d metrics", n)
+	}
+}
+
+func TestMetrics_FingerprintLimit(t *testing.T) {
+	m, _ := newMetrics(t, Options{Namespace: "limited", MaxFingerprints: 2})
+
+	for _, fp := range []string{"a", "b", "c", "a"} {
+		m.observeEntry(context.Background(), nil, errs.Entry{Fingerprint: fp})
+	}
+
+	if got := testutil.ToFloat64(m.entries.WithLabelValues("INFO", "", "", "a")); got != 2 {
+		t.Fatalf("Expected known fingerprints to keep their label, got %v", got)
+	}
+	if got := testutil.ToFloat64(m.entries.WithLabelValues("INFO", "", "", OtherFingerprint)); got != 1 {
+		t.Fatalf("Expected fingerprints beyond the limit to be counted as other, got %v", got)
+	}
+}
+
+func TestNew_AlreadyRegistered(t *testing.T) {
+	_, reg := newMetrics(t, Options{})
+
+	if _, err := New(reg, Options{}); err == nil {
+		t.Fatal("Expected an error when the metrics are already registered")
+	}
+}
+
+func TestClose(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m, err := New(reg, Options{})
+	if err != nil {
+		t.Fatal("Expected no error, got", err)
+	}
+	m.Close()
+
+	m, err = New(reg, Options{})
+	if err != nil {
+		t.Fatal("Expected the metrics to be unregistered on close, got", err)
+	}
+	m.Close()
+}
@@ -0,0 +1,266 @@
+package errs
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// defaultRecentSize is the number of entries kept in memory by default, see SetRecentSize.
+	defaultRecentSize = 1000
+	// recentRequestLength is the maximum length of the request and of each field value
+	// kept with a recent entry.
+	recentRequestLength = 1024
+)
+
+// recentEntries keeps the last entries logged by every logger.
+var recentEntries = &recentBuffer{entries: make([]Entry, defaultRecentSize)}
+
+// recentBuffer is a ring buffer of the last entries.
+type recentBuffer struct {
+	mu      sync.Mutex
+	entries []Entry
+	next    int  // Index the next entry is written to.
+	full    bool // Whether the buffer wrapped around.
+}
+
+// RecentQuery filters the entries returned by Recent and RecentGroups.
+// All conditions that are set must match; a zero query matches every entry.
+type RecentQuery struct {
+	Since  time.Time    // Entries logged at or after Since.
+	Until  time.Time    // Entries logged before Until.
+	Levels []slog.Level // Entry levels to include.
+	Code   string       // Error code the entries must have.
+	Text   string       // Text the message, the Error Path or the root cause must contain, ignoring case.
+	Limit  int          // Maximum number of results; zero returns all of them.
+}
+
+// RecentGroup is a group of recent entries with the same fingerprint.
+type RecentGroup struct {
+	Fingerprint string
+	Count       int
+	FirstSeen   time.Time
+	LastSeen    time.Time
+	Last        Entry // Most recent entry of the group.
+}
+
+// SetRecentSize sets the number of entries kept in memory for Recent and RecentGroups.
+// The most recent entries are kept when the buffer shrinks. Zero disables the buffer.
+//
+// Parameters:
+//   - n: The number of entries to keep. Negative values are treated as zero.
+func SetRecentSize(n int) {
+	recentEntries.resize(max(n, 0))
+}
+
+// Recent returns the entries kept in memory that match the query, most recent first.
+// Every logged entry is kept, including muted and throttled ones, with its request
+// converted to JSON and truncated to 1024 bytes. Field values are truncated the same way;
+// numbers, booleans and short strings are kept as they are.
+//
+// Parameters:
+//   - q: The query the entries must match.
+//
+// Returns:
+//   - The matching entries, at most q.Limit of them if it is set.
+func Recent(q RecentQuery) []Entry {
+	var matched []Entry
+	recentEntries.each(func(e Entry) bool {
+		if q.matches(e) {
+			matched = append(matched, e)
+		}
+		return q.Limit <= 0 || len(matched) < q.Limit
+	})
+	return matched
+}
+
+// RecentGroups groups the entries kept in memory that match the query by fingerprint,
+// answering what the process has been failing on.
+//
+// Parameters:
+//   - q: The query the entries must match. q.Limit limits the number of groups.
+//
+// Returns:
+//   - The groups, largest first and, for groups of the same size, most recent first.
+func RecentGroups(q RecentQuery) []RecentGroup {
+	limit := q.Limit
+	q.Limit = 0
+
+	byFingerprint := map[string]*RecentGroup{}
+	var groups []*RecentGroup
+	for _, e := range Recent(q) {
+		g, ok := byFingerprint[e.Fingerprint]
+		if !ok {
+			// Entries are most recent first, so the first one of a group is its last.
+			g = &RecentGroup{Fingerprint: e.Fingerprint, LastSeen: e.Time, Last: e}
+			byFingerprint[e.Fingerprint] = g
+			groups = append(groups, g)
+		}
+		g.Count++
+		g.FirstSeen = e.Time
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].Count > groups[j].Count })
+	if limit > 0 && len(groups) > limit {
+		groups = groups[:limit]
+	}
+
+	result := make([]RecentGroup, len(groups))
+	for i, g := range groups {
+		result[i] = *g
+	}
+	return result
+}
+
+// matches reports whether the entry satisfies every condition of the query.
+func (q RecentQuery) matches(e Entry) bool {
+	if !q.Since.IsZero() && e.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !e.Time.Before(q.Until) {
+		return false
+	}
+	if len(q.Levels) > 0 && !slices.Contains(q.Levels, e.Level) {
+		return false
+	}
+	if q.Code != "" && e.Code != q.Code {
+		return false
+	}
+	if q.Text != "" {
+		text := strings.ToLower(q.Text)
+		if !strings.Contains(strings.ToLower(e.Message), text) &&
+			!strings.Contains(strings.ToLower(e.ErrorPath), text) &&
+			!strings.Contains(strings.ToLower(e.RootCause), text) {
+			return false
+		}
+	}
+	return true
+}
+
+// add keeps an entry, replacing the oldest one when the buffer is full.
+// The request and the field values are stored as truncated JSON,
+// so that the buffer does not retain large objects.
+func (b *recentBuffer) add(e Entry) {
+	e.Request = recentRequest(e.Request)
+	e.Fields = recentFields(e.Fields)
+	e.ctx = nil
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.entries) == 0 {
+		return
+	}
+	b.entries[b.next] = e
+	b.next = (b.next + 1) % len(b.entries)
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+// each calls fn for every entry, most recent first, until fn returns false.
+func (b *recentBuffer) each(fn func(Entry) bool) {
+	b.mu.Lock()
+	entries := b.ordered()
+	b.mu.Unlock()
+
+	for _, e := range entries {
+		if !fn(e) {
+			return
+		}
+	}
+}
+
+// ordered returns a copy of the entries, most recent first. The caller must hold b.mu.
+func (b *recentBuffer) ordered() []Entry {
+	n := b.next
+	if b.full {
+		n = len(b.entries)
+	}
+
+	entries := make([]Entry, 0, n)
+	for i := 1; i <= n; i++ {
+		entries = append(entries, b.entries[(b.next-i+len(b.entries))%len(b.entries)])
+	}
+	return entries
+}
+
+// resize changes the capacity of the buffer, keeping the most recent entries.
+func (b *recentBuffer) resize(n int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	entries := b.ordered()
+	if len(entries) > n {
+		entries = entries[:n]
+	}
+	slices.Reverse(entries)
+
+	b.entries = make([]Entry, n)
+	copy(b.entries, entries)
+	b.next = len(entries)
+	b.full = false
+	if n > 0 && b.next == n {
+		b.next = 0
+		b.full = true
+	}
+}
+
+// recentRequest converts a request to JSON, truncated to recentRequestLength bytes.
+// Requests that cannot be converted are formatted with fmt.
+func recentRequest(req any) any {
+	if req == nil {
+		return nil
+	}
+
+	var text string
+	if data, err := json.Marshal(req); err == nil {
+		text = string(data)
+	} else {
+		text = fmt.Sprint(req)
+	}
+
+	return truncateRecent(text)
+}
+
+// recentFields returns a copy of the fields of an entry to keep in memory. Numbers, booleans
+// and strings are kept, strings truncated to recentRequestLength bytes; other values are
+// converted like requests, see recentRequest.
+func recentFields(fields map[string]any) map[string]any {
+	if fields == nil {
+		return nil
+	}
+
+	kept := make(map[string]any, len(fields))
+	for k, v := range fields {
+		switch v := v.(type) {
+		case nil, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+			kept[k] = v
+		case string:
+			kept[k] = truncateRecent(v)
+		default:
+			kept[k] = recentRequest(v)
+		}
+	}
+	return kept
+}
+
+// truncateRecent truncates text to recentRequestLength bytes, without cutting a character.
+func truncateRecent(text string) string {
+	if len(text) <= recentRequestLength {
+		return text
+	}
+
+	cut := recentRequestLength
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut] + "…"
+}
@@ -0,0 +1,117 @@
+package errs
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecentBuffer(t *testing.T) {
+	b := &recentBuffer{entries: make([]Entry, 3)}
+	for _, msg := range []string{"1", "2", "3", "4"} {
+		b.add(Entry{Message: msg})
+	}
+
+	var got []string
+	b.each(func(e Entry) bool {
+		got = append(got, e.Message)
+		return true
+	})
+	if strings.Join(got, ",") != "4,3,2" {
+		t.Fatalf("Expected the last 3 entries, most recent first, got %v", got)
+	}
+
+	b.resize(2)
+	b.add(Entry{Message: "5"})
+	got = got[:0]
+	b.each(func(e Entry) bool {
+		got = append(got, e.Message)
+		return true
+	})
+	if strings.Join(got, ",") != "5,4" {
+		t.Fatalf("Expected the most recent entries to be kept on resize, got %v", got)
+	}
+
+	b.resize(0)
+	b.add(Entry{Message: "6"})
+	b.each(func(e Entry) bool {
+		t.Fatalf("Expected no entries in a disabled buffer, got %+v", e)
+		return false
+	})
+}
+
+func TestRecent(t *testing.T) {
+	resetStats(t)
+	now := time.Now()
+	Default().getLogger(Entry{Time: now.Add(-time.Hour), Level: slog.LevelError, Code: "recent_test", Message: "old", Fingerprint: "a"})
+	Default().getLogger(Entry{Time: now, Level: slog.LevelWarn, Code: "recent_test", Message: "Dial failed", Fingerprint: "b"})
+	Default().getLogger(Entry{Time: now, Level: slog.LevelError, Code: "recent_test", Message: "query failed", Fingerprint: "a"})
+
+	if got := Recent(RecentQuery{Code: "recent_test"}); len(got) != 3 || got[0].Message != "query failed" {
+		t.Fatalf("Expected 3 entries, most recent first, got %+v", got)
+	}
+	if got := Recent(RecentQuery{Code: "recent_test", Since: now.Add(-time.Minute)}); len(got) != 2 {
+		t.Fatalf("Expected 2 entries since a minute ago, got %d", len(got))
+	}
+	if got := Recent(RecentQuery{Code: "recent_test", Levels: []slog.Level{slog.LevelWarn}}); len(got) != 1 {
+		t.Fatalf("Expected 1 warning, got %d", len(got))
+	}
+	if got := Recent(RecentQuery{Code: "recent_test", Text: "dial"}); len(got) != 1 || got[0].Message != "Dial failed" {
+		t.Fatalf("Expected the text to match ignoring case, got %+v", got)
+	}
+	if got := Recent(RecentQuery{Code: "recent_test", Limit: 1}); len(got) != 1 {
+		t.Fatalf("Expected the limit to apply, got %d", len(got))
+	}
+
+	groups := RecentGroups(RecentQuery{Code: "recent_test"})
+	if len(groups) != 2 || groups[0].Fingerprint != "a" || groups[0].Count != 2 {
+		t.Fatalf("Expected the largest group first, got %+v", groups)
+	}
+	if !groups[0].FirstSeen.Equal(now.Add(-time.Hour)) || !groups[0].LastSeen.Equal(now) || groups[0].Last.Message != "query failed" {
+		t.Fatalf("Unexpected group %+v", groups[0])
+	}
+}
+
+func TestRecentRequest(t *testing.T) {
+	if recentRequest(nil) != nil {
+		t.Fatal("Expected no request")
+	}
+	if got := recentRequest(map[string]int{"id": 1}); got != `{"id":1}` {
+		t.Fatalf("Expected the request as JSON, got %v", got)
+	}
+
+	got := recentRequest(strings.Repeat("é", recentRequestLength)).(string)
+	if len(got) > recentRequestLength+len("…") || !strings.HasSuffix(got, "…") {
+		t.Fatalf("Expected the request to be truncated, got %d bytes", len(got))
+	}
+	if !strings.HasPrefix(got, `"éé`) || strings.ContainsRune(got, '�') {
+		t.Fatal("Expected the request not to be cut inside a character")
+	}
+}
+
+func TestRecentFields(t *testing.T) {
+	if recentFields(nil) != nil {
+		t.Fatal("Expected no fields")
+	}
+
+	fields := map[string]any{
+		"user":    int64(7),
+		"tenant":  "acme",
+		"payload": strings.Repeat("x", 2*recentRequestLength),
+		"object":  map[string]any{"data": strings.Repeat("y", 2*recentRequestLength)},
+	}
+	got := recentFields(fields)
+	if got["user"] != int64(7) || got["tenant"] != "acme" {
+		t.Fatalf("Expected scalar fields to be kept, got %v", got)
+	}
+	for _, key := range []string{"payload", "object"} {
+		value, ok := got[key].(string)
+		if !ok || len(value) > recentRequestLength+len("…") || !strings.HasSuffix(value, "…") {
+			t.Fatalf("Expected field %s to be truncated, got %v", key, got[key])
+		}
+	}
+	if len(fields["payload"].(string)) != 2*recentRequestLength {
+		t.Fatal("Expected the fields of the entry to be left unchanged")
+	}
+}
@@ -0,0 +1,166 @@
+package errs
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	botV5 "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// repeatCounterLength is the room left in new messages for the occurrence counter line.
+const repeatCounterLength = 64
+
+// repeatEditInterval is the minimum time between two edits of the same alert,
+// to stay below the Telegram rate limits when an error repeats in a loop.
+var repeatEditInterval = 3 * time.Second
+
+// repeats remembers the alerts sent per chat and fingerprint, so that repeats edit them.
+type repeats struct {
+	window time.Duration
+
+	mu     sync.Mutex
+	alerts map[repeatKey]*repeatAlert
+}
+
+// repeatKey identifies the alert of a fingerprint in a chat.
+type repeatKey struct {
+	chat        ChatTarget
+	fingerprint string
+}
+
+// repeatAlert is an alert sent to a chat and the occurrences of its error since.
+// Its mutex is held while the alert is sent or edited, so concurrent repeats wait for the first message.
+type repeatAlert struct {
+	mu       sync.Mutex
+	sent     sentMessage
+	first    time.Time
+	last     time.Time
+	count    int
+	edited   time.Time   // Time of the last edit.
+	deferred *time.Timer // Pending edit, when the last one was too recent.
+	notes    []string    // Notes of the buttons pressed on the alert, see handleCallback.
+}
+
+// newRepeats creates an empty repeat tracker that sends a new alert after window.
+func newRepeats(window time.Duration) *repeats {
+	return &repeats{window: window, alerts: map[repeatKey]*repeatAlert{}}
+}
+
+// alert returns the alert of a fingerprint in a chat, forgetting alerts older than the window.
+func (r *repeats) alert(key repeatKey, now time.Time) *repeatAlert {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for k, a := range r.alerts {
+		// Alerts being sent or edited are busy, not expired.
+		if !a.mu.TryLock() {
+			continue
+		}
+		expired := !a.first.IsZero() && now.Sub(a.first) >= r.window
+		a.mu.Unlock()
+
+		if expired {
+			delete(r.alerts, k)
+		}
+	}
+
+	a, ok := r.alerts[key]
+	if !ok {
+		a = &repeatAlert{}
+		r.alerts[key] = a
+	}
+	return a
+}
+
+// addNote remembers the note of a button pressed on the alert sent as a message to a chat,
+// so that editing the alert keeps it.
+func (r *repeats) addNote(chatID int64, messageID int, note string) {
+	r.mu.Lock()
+	var alerts []*repeatAlert
+	for k, a := range r.alerts {
+		if k.chat.ChatID == chatID {
+			alerts = append(alerts, a)
+		}
+	}
+	r.mu.Unlock()
+
+	for _, a := range alerts {
+		a.mu.Lock()
+		if a.count > 0 && a.sent.id == messageID {
+			a.notes = append(a.notes, note)
+		}
+		a.mu.Unlock()
+	}
+}
+
+// sendRepeat sends an entry to the chats, editing the alert already sent for its fingerprint
+// within the window instead of sending a new message.
+func (bb *BroadcastBot) sendRepeat(ctx context.Context, chats []ChatTarget, e Entry, msg logMessage) error {
+	var errs error
+	for _, chat := range chats {
+		if err := bb.sendRepeatTo(ctx, chat, e, msg); err != nil {
+			errs = Join(" && ", errs, err)
+		}
+	}
+	return errs
+}
+
+// sendRepeatTo sends an entry to a single chat or counts it in the alert already sent there.
+func (bb *BroadcastBot) sendRepeatTo(ctx context.Context, chat ChatTarget, e Entry, msg logMessage) error {
+	a := bb.repeats.alert(repeatKey{chat: chat, fingerprint: e.Fingerprint}, e.Time)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.count == 0 {
+		sent, err := bb.sendLogTo(ctx, chat, msg)
+		if err != nil {
+			return err
+		}
+
+		a.sent, a.first, a.last, a.count = sent, e.Time, e.Time, 1
+		return nil
+	}
+
+	a.count++
+	a.last = e.Time
+
+	wait := repeatEditInterval - time.Since(a.edited)
+	if wait <= 0 {
+		return bb.editRepeat(ctx, chat, a)
+	}
+
+	if a.deferred == nil {
+		a.deferred = time.AfterFunc(wait, func() {
+			a.mu.Lock()
+			defer a.mu.Unlock()
+
+			a.deferred = nil
+			reportBotError(bb.editRepeat(context.Background(), chat, a))
+		})
+	}
+	return nil
+}
+
+// editRepeat edits an alert to show its occurrence counter, keeping the notes of the buttons
+// pressed on it. The caller must hold the alert mutex.
+func (bb *BroadcastBot) editRepeat(ctx context.Context, chat ChatTarget, a *repeatAlert) error {
+	counter := fmt.Sprintf("×%d occurrences, last at %s\n", a.count, a.last.Format("15:04:05"))
+	msg := a.sent.msg.prepend(counter, bb.parseMode)
+	for _, note := range a.notes {
+		msg = msg.append("\n\n"+note, bb.parseMode)
+	}
+
+	params := botV5.Params{
+		"chat_id":    strconv.FormatInt(chat.ChatID, 10),
+		"message_id": strconv.Itoa(a.sent.id),
+	}
+
+	a.edited = time.Now()
+	if _, err := bb.postMessage(ctx, "editMessageText", params, msg); err != nil {
+		return WrapF(err, "failed to edit message %d in chat %d", a.sent.id, chat.ChatID)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package errs
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	botV5 "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestBroadcastBot_EditRepeats(t *testing.T) {
+	interval := repeatEditInterval
+	repeatEditInterval = 50 * time.Millisecond
+	t.Cleanup(func() { repeatEditInterval = interval })
+
+	f := newFakeBotAPI(t)
+	setupFakeBot(t, f, BroadcastBotParams{ChatIDs: []int64{1}, EditRepeats: time.Hour, Lazy: true})
+
+	for i := 0; i < 3; i++ {
+		Default().getLogger(Entry{Time: time.Now(), Level: slog.LevelError, Fingerprint: "fp_repeat"})
+	}
+
+	if len(f.calls("sendMessage")) != 1 {
+		t.Fatalf("Expected repeats not to send new messages, got %d", len(f.calls("sendMessage")))
+	}
+
+	edits := f.waitCalls(t, "editMessageText", 2)
+	if !strings.HasPrefix(edits[0].Params.Get("text"), "×2 occurrences, last at ") {
+		t.Fatalf("Expected the first repeat to be counted, got %q", edits[0].Params.Get("text"))
+	}
+	if !strings.HasPrefix(edits[1].Params.Get("text"), "×3 occurrences, last at ") {
+		t.Fatalf("Expected the deferred edit to show the last count, got %q", edits[1].Params.Get("text"))
+	}
+	// The fake numbers messages by request, so the first message of a lazy bot has ID 1.
+	if edits[1].Params.Get("message_id") != "1" {
+		t.Fatalf("Expected the first message to be edited, got %v", edits[1].Params)
+	}
+
+	Default().getLogger(Entry{Time: time.Now(), Level: slog.LevelError, Fingerprint: "fp_other"})
+	if len(f.calls("sendMessage")) != 2 {
+		t.Fatal("Expected another fingerprint to send a new message")
+	}
+}
+
+func TestBroadcastBot_EditRepeats_Acknowledged(t *testing.T) {
+	f := newFakeBotAPI(t)
+	f.respond = func(req fakeRequest) any {
+		if req.Method == "sendMessage" {
+			return map[string]any{"message_id": 42, "date": time.Now().Unix()}
+		}
+		return nil
+	}
+	setupFakeBot(t, f, BroadcastBotParams{ChatIDs: []int64{1}, Buttons: true, EditRepeats: time.Hour, Lazy: true})
+
+	bb := Default().Bot()
+	Default().getLogger(Entry{Time: time.Now(), Level: slog.LevelError, Fingerprint: "fp_acked"})
+	cq := &botV5.CallbackQuery{
+		ID:      "cb",
+		From:    &botV5.User{UserName: "alice"},
+		Data:    "ack:fp_acked",
+		Message: &botV5.Message{MessageID: 42, Chat: &botV5.Chat{ID: 1}, Text: "alert"},
+	}
+	if err := bb.handleCallback(context.Background(), cq, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	Default().getLogger(Entry{Time: time.Now(), Level: slog.LevelError, Fingerprint: "fp_acked"})
+	edits := f.waitCalls(t, "editMessageText", 2)
+	text := edits[1].Params.Get("text")
+	if !strings.HasPrefix(text, "×2 occurrences, last at ") || !strings.Contains(text, "\n\nAcknowledged by @alice at ") {
+		t.Fatalf("Expected the repeat to keep the acknowledgement, got %q", text)
+	}
+}
+
+func TestBroadcastBot_EditRepeats_Window(t *testing.T) {
+	f := newFakeBotAPI(t)
+	setupFakeBot(t, f, BroadcastBotParams{ChatIDs: []int64{1}, EditRepeats: time.Minute, Lazy: true})
+
+	Default().getLogger(Entry{Time: time.Now().Add(-time.Hour), Level: slog.LevelError, Fingerprint: "fp_window"})
+	Default().getLogger(Entry{Time: time.Now(), Level: slog.LevelError, Fingerprint: "fp_window"})
+
+	if len(f.calls("sendMessage")) != 2 {
+		t.Fatal("Expected a new message once the window has passed")
+	}
+}
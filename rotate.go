@@ -0,0 +1,236 @@
+package errs
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rotationTimeFormat is the time format of the names of rotated log files.
+const rotationTimeFormat = "2006-01-02T15-04-05.000"
+
+// RotationParams configures the rotation of the log file, see SetLogRotation.
+type RotationParams struct {
+	MaxSizeMB  int           `json:"max_size_mb"` // Size in megabytes after which the file is rotated. Zero disables rotation.
+	MaxBackups int           `json:"max_backups"` // Rotated files to keep. Zero keeps all of them.
+	MaxAge     time.Duration `json:"max_age"`     // Rotated files older than MaxAge are removed. Zero keeps them.
+}
+
+// logFile is the file written by the file logger. It renames itself with a timestamp
+// and starts a new file once it grows past the size of its rotation.
+type logFile struct {
+	path string
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	rotation RotationParams
+	refs     int  // Writers holding the file open, see acquire.
+	retired  bool // Replaced by another file; closed once refs drops to zero.
+}
+
+// SetLogRotation rotates the log file set with SetLogFile once it grows past a size.
+// The rotated file is renamed with the time of the rotation, for example
+// logger-2024-05-01T10-00-00.000.json, and old rotated files are removed.
+//
+// Parameters:
+//   - params: The size, number and age limits. Zero params disable rotation.
+//
+// Returns:
+//   - An error if a parameter is negative.
+func SetLogRotation(params RotationParams) error {
+	if params.MaxSizeMB < 0 || params.MaxBackups < 0 || params.MaxAge < 0 {
+		return New("invalid log rotation: negative parameters")
+	}
+
+	return updateSettings(func(s *settings) error {
+		s.rotation = params
+		if s.logFile != nil {
+			s.logFile.setRotation(params)
+		}
+		return nil
+	})
+}
+
+// openLogFile creates the directory of the log file and opens the file for appending.
+func openLogFile(filePath string, rotation RotationParams) (*logFile, error) {
+	if filePath == "" {
+		return nil, New("invalid file path")
+	}
+
+	// Ensure the directory for the log file exists.
+	dir := filepath.Dir(filePath)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, Wrap(err, "failed to create log directory")
+	}
+
+	f := &logFile{path: filePath, rotation: rotation}
+	if err := f.open(); err != nil {
+		return nil, Wrap(err, "failed to set log file")
+	}
+	return f, nil
+}
+
+// Name returns the path of the log file.
+func (f *logFile) Name() string {
+	return f.path
+}
+
+// Write appends p to the file, rotating it first if p does not fit into the maximum size.
+func (f *logFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	if maxSize := int64(f.rotation.MaxSizeMB) << 20; maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Close closes the file. Later writes fail.
+func (f *logFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// acquire holds the file open for a writer until it calls release.
+// It reports false if the file was replaced, in which case it must not be written to.
+func (f *logFile) acquire() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.retired {
+		return false
+	}
+	f.refs++
+	return true
+}
+
+// release releases a hold taken with acquire, closing the file if it was
+// replaced and this was the last writer.
+func (f *logFile) release() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.refs--
+	if f.retired && f.refs == 0 && f.file != nil {
+		_ = f.file.Close()
+		f.file = nil
+	}
+}
+
+// retire marks a replaced file, closing it once no writer holds it. It does nothing for nil.
+func (f *logFile) retire() {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.retired = true
+	if f.refs == 0 && f.file != nil {
+		_ = f.file.Close()
+		f.file = nil
+	}
+}
+
+// setRotation changes the rotation of the file.
+func (f *logFile) setRotation(params RotationParams) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.rotation = params
+}
+
+// open opens the file for appending. The caller must hold f.mu unless f is not shared yet.
+func (f *logFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+// rotate renames the file with the current time, opens a new one and removes old rotated files.
+// The caller must hold f.mu.
+func (f *logFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return Wrap(err, "failed to rotate log file")
+	}
+	f.file = nil
+
+	ext := filepath.Ext(f.path)
+	rotated := strings.TrimSuffix(f.path, ext) + "-" + time.Now().Format(rotationTimeFormat) + ext
+	if err := os.Rename(f.path, rotated); err != nil {
+		return Wrap(err, "failed to rotate log file")
+	}
+	if err := f.open(); err != nil {
+		return Wrap(err, "failed to rotate log file")
+	}
+
+	f.removeBackups()
+	return nil
+}
+
+// removeBackups removes the rotated files beyond MaxBackups or older than MaxAge.
+func (f *logFile) removeBackups() {
+	if f.rotation.MaxBackups == 0 && f.rotation.MaxAge == 0 {
+		return
+	}
+
+	ext := filepath.Ext(f.path)
+	prefix := strings.TrimSuffix(f.path, ext) + "-"
+	matches, err := filepath.Glob(prefix + "*" + ext)
+	if err != nil {
+		return
+	}
+	// Only names with a rotation timestamp are backups; other files sharing the prefix,
+	// such as app-errors.json next to app.json, are left alone.
+	var backups []string
+	for _, match := range matches {
+		stamp := strings.TrimSuffix(strings.TrimPrefix(match, prefix), ext)
+		if _, err := time.Parse(rotationTimeFormat, stamp); err == nil {
+			backups = append(backups, match)
+		}
+	}
+	// The timestamps sort chronologically, so the newest backups come first.
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+
+	for i, backup := range backups {
+		expired := false
+		if f.rotation.MaxAge > 0 {
+			if info, err := os.Stat(backup); err == nil && time.Since(info.ModTime()) > f.rotation.MaxAge {
+				expired = true
+			}
+		}
+		if expired || f.rotation.MaxBackups > 0 && i >= f.rotation.MaxBackups {
+			_ = os.Remove(backup)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package errs
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLogFile_Rotate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "errors.json")
+	unrelated := filepath.Join(dir, "errors-foo.json")
+	if err := os.WriteFile(unrelated, []byte("keep"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	f, err := openLogFile(path, RotationParams{MaxSizeMB: 1, MaxBackups: 1})
+	if err != nil {
+		t.Fatal("Expected no error, got", err)
+	}
+	defer f.Close()
+
+	chunk := bytes.Repeat([]byte("x"), 600<<10)
+	for i := 0; i < 5; i++ {
+		if _, err := f.Write(chunk); err != nil {
+			t.Fatal("Expected no error, got", err)
+		}
+		time.Sleep(2 * time.Millisecond) // Rotated files are named to the millisecond.
+	}
+
+	backups, _ := filepath.Glob(filepath.Join(dir, "errors-2*.json"))
+	if len(backups) != 1 {
+		t.Fatalf("Expected 1 rotated file to be kept, got %v", backups)
+	}
+	if _, err := os.Stat(unrelated); err != nil {
+		t.Fatal("Expected a file that is not a backup to be kept, got", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Size() != int64(len(chunk)) {
+		t.Fatalf("Expected the current file to hold the last write, got %v %v", info, err)
+	}
+
+	if err := SetLogRotation(RotationParams{MaxBackups: -1}); err == nil {
+		t.Fatal("Expected negative parameters to be rejected")
+	}
+}
+
+func TestLogFile_NoRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "errors.json")
+	f, err := openLogFile(path, RotationParams{})
+	if err != nil {
+		t.Fatal("Expected no error, got", err)
+	}
+
+	chunk := bytes.Repeat([]byte("x"), 1<<20)
+	for i := 0; i < 2; i++ {
+		if _, err := f.Write(chunk); err != nil {
+			t.Fatal("Expected no error, got", err)
+		}
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() != 2<<20 {
+		t.Fatalf("Expected the file not to be rotated, got %v %v", info, err)
+	}
+
+	if err := f.Close(); err != nil {
+		t.Fatal("Expected no error, got", err)
+	}
+	if _, err := f.Write(chunk); err == nil {
+		t.Fatal("Expected writes to a closed file to fail")
+	}
+}
@@ -0,0 +1,153 @@
+package errs
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+)
+
+// ChatTarget is a Telegram chat, optionally narrowed to a forum topic.
+type ChatTarget struct {
+	ChatID   int64 `json:"chat_id"`             // Chat to send the message to.
+	ThreadID int   `json:"thread_id,omitempty"` // Forum topic (message_thread_id) within the chat; zero for the general topic.
+}
+
+// Route sends the entries it matches to a set of Telegram chats.
+//
+// All conditions that are set must match for the route to apply; a route without
+// conditions matches every entry. Routes are evaluated in order and the first
+// matching route wins.
+type Route struct {
+	Levels   []slog.Level      // Entry levels the route applies to.
+	Codes    []string          // Error codes the route applies to.
+	Services []string          // Service names the route applies to.
+	Fields   map[string]string // Entry fields that must have the given values, compared as text.
+	Message  *regexp.Regexp    // Pattern the message or the Error Path must match.
+	Match    func(Entry) bool  // Custom condition.
+
+	Chats     []ChatTarget  // Chats the matched entries are sent to.
+	CopyToAll bool          // Also send the matched entries to every chat in BroadcastBotParams.ChatIDs.
+	Bot       *BroadcastBot // Send the matched entries through another bot instead, using its own routes.
+	Template  string        // Notification template for the matched entries, see BroadcastBotParams.Template.
+
+	tmpl *notificationTemplate
+}
+
+// matches reports whether the entry satisfies every condition of the route.
+func (r Route) matches(e Entry) bool {
+	if len(r.Levels) > 0 && !slices.Contains(r.Levels, e.Level) {
+		return false
+	}
+	if len(r.Codes) > 0 && !slices.Contains(r.Codes, e.Code) {
+		return false
+	}
+	if len(r.Services) > 0 && !slices.Contains(r.Services, e.Service) {
+		return false
+	}
+	for key, value := range r.Fields {
+		v, ok := e.Fields[key]
+		if !ok || fmt.Sprint(v) != value {
+			return false
+		}
+	}
+	if r.Message != nil && !r.Message.MatchString(e.Message) && !r.Message.MatchString(e.ErrorPath) {
+		return false
+	}
+	if r.Match != nil && !r.Match(e) {
+		return false
+	}
+	return true
+}
+
+// botRoutes is an immutable snapshot of the routes of a bot. SetRoutes replaces it as a whole,
+// so that an entry is routed and formatted with one consistent set of routes.
+type botRoutes struct {
+	routes       []Route
+	defaultRoute []ChatTarget
+}
+
+// SetRoutes replaces the routes and the default route of the bot, see BroadcastBotParams.Routes.
+// It is safe to call while entries are being sent; they finish with the routes they started with.
+//
+// Parameters:
+//   - routes: The routes, evaluated in order. Their templates are parsed like BroadcastBotParams.Template.
+//   - defaultRoute: The chats of the entries no route matches. Empty sends them to all chats.
+//
+// Returns:
+//   - An error if a route template is invalid, in which case the routes are not changed.
+func (bb *BroadcastBot) SetRoutes(routes []Route, defaultRoute []ChatTarget) error {
+	routing := &botRoutes{
+		routes:       append([]Route(nil), routes...),
+		defaultRoute: append([]ChatTarget(nil), defaultRoute...),
+	}
+
+	var err error
+	for i := range routing.routes {
+		if routing.routes[i].tmpl, err = bb.parseTemplate(routing.routes[i].Template); err != nil {
+			return WrapF(err, "route %d", i)
+		}
+	}
+
+	bb.routing.Store(routing)
+	return nil
+}
+
+// routes returns the current routes of the bot.
+func (bb *BroadcastBot) routes() *botRoutes {
+	if routing := bb.routing.Load(); routing != nil {
+		return routing
+	}
+	return &botRoutes{}
+}
+
+// match returns the first route matching the entry, or nil if none does.
+func (r *botRoutes) match(e Entry) *Route {
+	for i := range r.routes {
+		if r.routes[i].matches(e) {
+			return &r.routes[i]
+		}
+	}
+	return nil
+}
+
+// route returns the chats an entry is sent to: the chats of the first matching route,
+// or the default route when none matches. Without a default route, entries go to all chats.
+func (bb *BroadcastBot) route(e Entry) []ChatTarget {
+	return bb.routeWith(bb.routes(), e)
+}
+
+// routeWith returns the chats an entry is sent to with the given routes, see route.
+func (bb *BroadcastBot) routeWith(routing *botRoutes, e Entry) []ChatTarget {
+	if r := routing.match(e); r != nil {
+		if r.CopyToAll {
+			return appendChats(r.Chats, bb.allChats()...)
+		}
+		return r.Chats
+	}
+
+	if len(routing.defaultRoute) > 0 {
+		return routing.defaultRoute
+	}
+	return bb.allChats()
+}
+
+// allChats returns the chats configured in BroadcastBotParams.ChatIDs.
+func (bb *BroadcastBot) allChats() []ChatTarget {
+	chats := make([]ChatTarget, 0, len(bb.chatIDs))
+	for _, id := range bb.chatIDs {
+		chats = append(chats, ChatTarget{ChatID: id})
+	}
+	return chats
+}
+
+// appendChats appends the chats that are not in dst yet.
+func appendChats(dst []ChatTarget, chats ...ChatTarget) []ChatTarget {
+	dst = append([]ChatTarget(nil), dst...)
+	for _, c := range chats {
+		if !slices.Contains(dst, c) {
+			dst = append(dst, c)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,75 @@
+package errs
+
+import (
+	"log/slog"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestRoute_Matches(t *testing.T) {
+	e := Entry{
+		Level:     slog.LevelError,
+		Service:   "billing",
+		Message:   "charge failed",
+		ErrorPath: "charge card ---> declined",
+		Code:      "payment_declined",
+		Fields:    map[string]any{"tenant": 42},
+	}
+
+	tests := []struct {
+		name  string
+		route Route
+		want  bool
+	}{
+		{"no conditions", Route{}, true},
+		{"level", Route{Levels: []slog.Level{slog.LevelError}}, true},
+		{"other level", Route{Levels: []slog.Level{slog.LevelWarn}}, false},
+		{"code", Route{Codes: []string{"payment_declined"}}, true},
+		{"other code", Route{Codes: []string{"not_found"}}, false},
+		{"service", Route{Services: []string{"billing"}}, true},
+		{"field", Route{Fields: map[string]string{"tenant": "42"}}, true},
+		{"missing field", Route{Fields: map[string]string{"user": "1"}}, false},
+		{"message", Route{Message: regexp.MustCompile(`^charge`)}, true},
+		{"error path", Route{Message: regexp.MustCompile(`declined$`)}, true},
+		{"other message", Route{Message: regexp.MustCompile(`timeout`)}, false},
+		{"predicate", Route{Match: func(e Entry) bool { return e.Service == "auth" }}, false},
+		{"all conditions", Route{Codes: []string{"payment_declined"}, Services: []string{"auth"}}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.route.matches(e); got != tt.want {
+			t.Errorf("%s: matches() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBroadcastBot_Route(t *testing.T) {
+	payments := ChatTarget{ChatID: 1, ThreadID: 7}
+	bb := &BroadcastBot{chatIDs: []int64{1, 2}}
+	routes := []Route{
+		{Codes: []string{"payment_declined"}, Chats: []ChatTarget{payments}},
+		{Codes: []string{"fatal"}, Chats: []ChatTarget{payments}, CopyToAll: true},
+	}
+
+	tests := []struct {
+		name         string
+		code         string
+		defaultRoute []ChatTarget
+		want         []ChatTarget
+	}{
+		{"matched route", "payment_declined", nil, []ChatTarget{payments}},
+		{"copy to all", "fatal", nil, []ChatTarget{payments, {ChatID: 1}, {ChatID: 2}}},
+		{"all chats", "other", nil, []ChatTarget{{ChatID: 1}, {ChatID: 2}}},
+		{"default route", "other", []ChatTarget{{ChatID: 3}}, []ChatTarget{{ChatID: 3}}},
+	}
+
+	for _, tt := range tests {
+		if err := bb.SetRoutes(routes, tt.defaultRoute); err != nil {
+			t.Fatal("Expected no error, got", err)
+		}
+		if got := bb.route(Entry{Code: tt.code}); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: route() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
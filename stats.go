@@ -0,0 +1,146 @@
+package errs
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// statsMinutes is the number of one-minute buckets kept per fingerprint.
+	statsMinutes = 60
+	// maxStatsFingerprints is the number of fingerprints counted at most. Once it is reached,
+	// the fingerprint seen least recently is forgotten to count a new one.
+	maxStatsFingerprints = 10000
+)
+
+// errorStats counts the logged entries per fingerprint over the last hour.
+var errorStats = &fingerprintStats{counters: map[string]*fingerprintCounter{}, limit: maxStatsFingerprints}
+
+// fingerprintStats counts entries per fingerprint in one-minute buckets.
+// Fingerprints without entries in the last hour are forgotten once a minute.
+type fingerprintStats struct {
+	mu       sync.Mutex
+	counters map[string]*fingerprintCounter
+	limit    int   // Number of fingerprints counted at most. Zero counts any number.
+	pruned   int64 // Minute expired fingerprints were last forgotten.
+}
+
+// fingerprintCounter is a ring of per-minute counts for one fingerprint.
+type fingerprintCounter struct {
+	errorPath string
+	last      int64               // Latest minute counted.
+	minutes   [statsMinutes]int64 // Minute (Unix time / 60) each bucket counts.
+	counts    [statsMinutes]int
+}
+
+// fingerprintCount is the number of entries with a fingerprint over the last hour.
+type fingerprintCount struct {
+	Fingerprint string
+	ErrorPath   string
+	Count       int
+}
+
+// record counts an entry.
+func (s *fingerprintStats) record(e Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	minute := e.Time.Unix() / 60
+	if minute > s.pruned {
+		s.prune(minute)
+	}
+
+	c, ok := s.counters[e.Fingerprint]
+	if !ok {
+		if s.limit > 0 && len(s.counters) >= s.limit {
+			s.evict()
+		}
+		c = &fingerprintCounter{}
+		s.counters[e.Fingerprint] = c
+	}
+	c.errorPath = e.ErrorPath
+	c.last = max(c.last, minute)
+
+	i := minute % statsMinutes
+	if c.minutes[i] != minute {
+		c.minutes[i] = minute
+		c.counts[i] = 0
+	}
+	c.counts[i]++
+}
+
+// prune forgets the fingerprints without entries in the hour before minute.
+// The caller must hold s.mu.
+func (s *fingerprintStats) prune(minute int64) {
+	s.pruned = minute
+	for fp, c := range s.counters {
+		if c.last <= minute-statsMinutes {
+			delete(s.counters, fp)
+		}
+	}
+}
+
+// evict forgets the fingerprint seen least recently. The caller must hold s.mu.
+func (s *fingerprintStats) evict() {
+	oldest := ""
+	for fp, c := range s.counters {
+		if oldest == "" || c.last < s.counters[oldest].last {
+			oldest = fp
+		}
+	}
+	delete(s.counters, oldest)
+}
+
+// top returns the counts of the last hour before now, most frequent first.
+// Fingerprints without entries in the last hour are forgotten.
+func (s *fingerprintStats) top(now time.Time) []fingerprintCount {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	since := now.Unix()/60 - statsMinutes
+	var counts []fingerprintCount
+	for fp, c := range s.counters {
+		n := 0
+		for i, minute := range c.minutes {
+			if minute > since {
+				n += c.counts[i]
+			}
+		}
+
+		if n == 0 {
+			delete(s.counters, fp)
+			continue
+		}
+		counts = append(counts, fingerprintCount{Fingerprint: fp, ErrorPath: c.errorPath, Count: n})
+	}
+
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Fingerprint < counts[j].Fingerprint
+	})
+	return counts
+}
+
+// series returns the per-minute counts of a fingerprint over the last hour before now,
+// oldest minute first.
+func (s *fingerprintStats) series(fingerprint string, now time.Time) []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counts := make([]int, statsMinutes)
+	c, ok := s.counters[fingerprint]
+	if !ok {
+		return counts
+	}
+
+	last := now.Unix() / 60
+	for i, minute := range c.minutes {
+		if age := last - minute; age >= 0 && age < statsMinutes {
+			counts[statsMinutes-1-age] += c.counts[i]
+		}
+	}
+	return counts
+}
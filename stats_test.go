@@ -0,0 +1,87 @@
+package errs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFingerprintStats(t *testing.T) {
+	s := &fingerprintStats{counters: map[string]*fingerprintCounter{}}
+	now := time.Now()
+
+	s.record(Entry{Time: now, Fingerprint: "a"})
+	s.record(Entry{Time: now, Fingerprint: "b"})
+	s.record(Entry{Time: now.Add(-time.Minute), Fingerprint: "b"})
+	s.record(Entry{Time: now.Add(-2 * time.Hour), Fingerprint: "c"})
+
+	counts := s.top(now)
+	if len(counts) != 2 {
+		t.Fatalf("Expected 2 fingerprints in the last hour, got %v", counts)
+	}
+	if counts[0].Fingerprint != "b" || counts[0].Count != 2 || counts[1].Fingerprint != "a" {
+		t.Fatalf("Expected the most frequent fingerprint first, got %v", counts)
+	}
+
+	if _, ok := s.counters["c"]; ok {
+		t.Fatal("Expected stale fingerprints to be forgotten")
+	}
+}
+
+func TestFingerprintStats_Series(t *testing.T) {
+	s := &fingerprintStats{counters: map[string]*fingerprintCounter{}}
+	now := time.Now()
+
+	s.record(Entry{Fingerprint: "a", Time: now.Add(-2 * time.Hour)})
+	s.record(Entry{Fingerprint: "a", Time: now.Add(-10 * time.Minute)})
+	s.record(Entry{Fingerprint: "a", Time: now})
+	s.record(Entry{Fingerprint: "a", Time: now})
+
+	series := s.series("a", now)
+	if len(series) != statsMinutes || series[statsMinutes-1] != 2 || series[statsMinutes-11] != 1 {
+		t.Fatalf("Unexpected series %v", series)
+	}
+	if got := s.series("unknown", now); len(got) != statsMinutes {
+		t.Fatalf("Expected an empty series for an unknown fingerprint, got %v", got)
+	}
+}
+
+func TestFingerprintStats_Bounded(t *testing.T) {
+	s := &fingerprintStats{counters: map[string]*fingerprintCounter{}, limit: 2}
+	now := time.Now()
+
+	s.record(Entry{Fingerprint: "old", Time: now.Add(-2 * time.Hour)})
+	s.record(Entry{Fingerprint: "a", Time: now.Add(-time.Minute)})
+	if _, ok := s.counters["old"]; ok {
+		t.Fatal("Expected fingerprints without entries in the last hour to be forgotten on record")
+	}
+
+	s.record(Entry{Fingerprint: "b", Time: now})
+	s.record(Entry{Fingerprint: "c", Time: now})
+	if len(s.counters) != 2 {
+		t.Fatalf("Expected at most 2 fingerprints, got %d", len(s.counters))
+	}
+	if _, ok := s.counters["a"]; ok {
+		t.Fatal("Expected the fingerprint seen least recently to be forgotten")
+	}
+}
+
+// resetStats clears the entry counts, the recent entries and the sink statistics kept by the
+// package, before the test and once it ends, so that tests counting entries are repeatable.
+func resetStats(t *testing.T) {
+	reset := func() {
+		errorStats.mu.Lock()
+		errorStats.counters, errorStats.pruned = map[string]*fingerprintCounter{}, 0
+		errorStats.mu.Unlock()
+
+		recentEntries.mu.Lock()
+		clear(recentEntries.entries)
+		recentEntries.next, recentEntries.full = 0, false
+		recentEntries.mu.Unlock()
+
+		sinkStatuses.Lock()
+		sinkStatuses.byName = map[string]*SinkStatus{}
+		sinkStatuses.Unlock()
+	}
+	reset()
+	t.Cleanup(reset)
+}
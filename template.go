@@ -0,0 +1,73 @@
+package errs
+
+import (
+	"encoding/json"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// notificationTemplate is a compiled notification template, see BroadcastBotParams.Template.
+// It is rendered in the parse mode of the bot, and as plain text for when Telegram rejects the formatting.
+type notificationTemplate struct {
+	formatted *template.Template
+	plain     *template.Template
+}
+
+// newNotificationTemplate parses a notification template for the parse mode.
+// Times are formatted with layout in loc; nil loc keeps the zone of the time,
+// an empty layout uses time.RFC3339Nano.
+func newNotificationTemplate(text string, mode ParseMode, loc *time.Location, layout string) (*notificationTemplate, error) {
+	formatted, err := template.New("notification").Funcs(templateFuncs(mode, loc, layout)).Parse(text)
+	if err != nil {
+		return nil, Wrap(err, "failed to parse notification template")
+	}
+
+	plain, err := template.New("notification").Funcs(templateFuncs(ParseModeText, loc, layout)).Parse(text)
+	if err != nil {
+		return nil, Wrap(err, "failed to parse notification template")
+	}
+
+	return &notificationTemplate{formatted: formatted, plain: plain}, nil
+}
+
+// message renders the template for an entry.
+func (t *notificationTemplate) message(e Entry) (logMessage, error) {
+	var formatted, plain strings.Builder
+	if err := t.formatted.Execute(&formatted, e); err != nil {
+		return logMessage{}, Wrap(err, "failed to execute notification template")
+	}
+	if err := t.plain.Execute(&plain, e); err != nil {
+		return logMessage{}, Wrap(err, "failed to execute notification template")
+	}
+
+	return logMessage{templated: true, formatted: formatted.String(), plain: plain.String()}, nil
+}
+
+// templateFuncs returns the helper functions of notification templates for the parse mode.
+func templateFuncs(mode ParseMode, loc *time.Location, layout string) template.FuncMap {
+	if layout == "" {
+		layout = time.RFC3339Nano
+	}
+
+	return template.FuncMap{
+		"escape": mode.escape,
+		"code":   mode.codeBlock,
+		"json": func(v any) (string, error) {
+			b, err := json.MarshalIndent(v, "", "  ")
+			return string(b), err
+		},
+		"truncate": func(n int, text string) string {
+			if textLength(text) <= n {
+				return text
+			}
+			return truncateText(text, n-1, textLength) + "…"
+		},
+		"formatTime": func(t time.Time) string {
+			if loc != nil {
+				t = t.In(loc)
+			}
+			return t.Format(layout)
+		},
+	}
+}
@@ -0,0 +1,89 @@
+package errs
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNotificationTemplate(t *testing.T) {
+	text := `{{ escape .Service }} {{ .Level }} at {{ formatTime .Time }}
+{{ escape (truncate 12 .ErrorPath) }} [{{ .RootCause }}]
+{{ code (json .Request) }}`
+
+	tmpl, err := newNotificationTemplate(text, ParseModeHTML, time.UTC, time.Kitchen)
+	if err != nil {
+		t.Fatal("Expected no error, got", err)
+	}
+
+	e := Entry{
+		Time:      time.Date(2024, 1, 2, 15, 4, 0, 0, time.FixedZone("UTC+3", 3*60*60)),
+		Level:     slog.LevelError,
+		Service:   "a<b>",
+		ErrorPath: "charge card ---> declined",
+		RootCause: "declined",
+		Request:   map[string]string{"id": "<1>"},
+	}
+
+	msg, err := tmpl.message(e)
+	if err != nil {
+		t.Fatal("Expected no error, got", err)
+	}
+
+	want := "a&lt;b&gt; ERROR at 12:04PM\ncharge card… [declined]\n<pre><code class=\"language-json\">{"
+	if got := msg.render(ParseModeHTML); !strings.HasPrefix(got, want) {
+		t.Fatalf("Unexpected formatted message %q", got)
+	}
+
+	if got := msg.render(ParseModeText); !strings.HasPrefix(got, "a<b> ERROR at 12:04PM\ncharge card… [declined]\n{") {
+		t.Fatalf("Unexpected plain message %q", got)
+	}
+}
+
+func TestNotificationTemplate_Invalid(t *testing.T) {
+	if _, err := newNotificationTemplate("{{ .Service", ParseModeText, nil, ""); err == nil {
+		t.Fatal("Expected a parse error")
+	}
+
+	f := newFakeBotAPI(t)
+	_, err := NewBot(BroadcastBotParams{
+		Token:       fakeBotToken,
+		APIEndpoint: f.URL,
+		ChatIDs:     []int64{1},
+		Routes:      []Route{{Template: "{{ nope }}"}},
+		Lazy:        true,
+	})
+	if err == nil {
+		t.Fatal("Expected an invalid route template to be rejected")
+	}
+}
+
+func TestBroadcastBot_Template(t *testing.T) {
+	f := newFakeBotAPI(t)
+	setupFakeBot(t, f, BroadcastBotParams{
+		ChatIDs:   []int64{1},
+		ParseMode: ParseModeMarkdownV2,
+		Template:  "*{{ escape .Message }}*",
+		Routes:    []Route{{Codes: []string{"payment"}, Chats: []ChatTarget{{ChatID: 1}}, Template: "Payment: {{ escape .Message }}"}},
+		Lazy:      true,
+	})
+
+	Default().getLogger(Entry{Time: time.Now(), Level: slog.LevelError, Message: "db_down."})
+	Default().getLogger(Entry{Time: time.Now(), Level: slog.LevelError, Message: "card.", Code: "payment"})
+	Default().getLogger(Entry{Time: time.Now(), Level: slog.LevelError, Message: strings.Repeat("x", maxMessageLength)})
+
+	sent := f.calls("sendMessage")
+	if len(sent) < 3 {
+		t.Fatalf("Expected 3 messages, got %d", len(sent))
+	}
+	if got := sent[0].Params.Get("text"); got != `*db\_down\.*` {
+		t.Fatalf("Unexpected bot template message %q", got)
+	}
+	if got := sent[1].Params.Get("text"); got != `Payment: card\.` {
+		t.Fatalf("Unexpected route template message %q", got)
+	}
+	if got := sent[2].Params.Get("text"); !strings.HasPrefix(got, "Service Name:") {
+		t.Fatalf("Expected a too long template to fall back to the default format, got %q", got[:40])
+	}
+}
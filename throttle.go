@@ -0,0 +1,181 @@
+package errs
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ThrottleParams configures the deduplication and rate limiting of a sink, see SetThrottle.
+type ThrottleParams struct {
+	// Window enables deduplication: of the entries with the same fingerprint, only the first
+	// Limit within Window are delivered. When the window closes, a summary entry reporting
+	// the number of suppressed entries is delivered instead of them. Zero disables deduplication.
+	Window time.Duration `json:"window"`
+	// Limit is the number of entries with the same fingerprint delivered per window. Zero means 1.
+	Limit int `json:"limit"`
+	// Rate limits the sink to this many entries per second on average. Zero disables rate limiting.
+	Rate float64 `json:"rate"`
+	// Burst is the number of entries the sink may deliver at once within the rate.
+	// Zero means Rate rounded up, but at least 1.
+	Burst int `json:"burst"`
+}
+
+// throttle deduplicates and rate limits the entries delivered to a sink.
+type throttle struct {
+	params ThrottleParams
+
+	mu      sync.Mutex
+	windows map[string]*dedupWindow // Open deduplication windows by fingerprint.
+	tokens  float64                 // Tokens left in the bucket.
+	last    time.Time               // Time the bucket was last refilled.
+}
+
+// dedupWindow counts the entries with one fingerprint within a deduplication window.
+type dedupWindow struct {
+	count      int   // Entries seen in the window.
+	suppressed int   // Entries that were not delivered.
+	sample     Entry // Last suppressed entry, used for the summary.
+}
+
+// throttles holds the throttle of every sink that has one, by sink name.
+var throttles = struct {
+	sync.RWMutex
+	bySink map[string]*throttle
+}{bySink: map[string]*throttle{}}
+
+// SetThrottle deduplicates and rate limits the entries delivered to a sink, such as a flood
+// of the same error while a dependency is down. Sinks are named after their LogType, for
+// example string(LogTypeFile), and BotSinkName for the broadcast bots, case-insensitively
+// like in Only. Entries that are not delivered are counted as suppressed in GetStatus.
+//
+// Parameters:
+//   - sink: The name of the sink to throttle.
+//   - params: The deduplication and rate limits. Zero params remove the throttle of the sink.
+//
+// Returns:
+//   - An error if a parameter is negative.
+func SetThrottle(sink string, params ThrottleParams) error {
+	if params.Window < 0 || params.Limit < 0 || params.Rate < 0 || params.Burst < 0 {
+		return NewF("invalid throttle for sink %s: negative parameters", sink)
+	}
+	if params.Limit == 0 {
+		params.Limit = 1
+	}
+	if params.Burst == 0 {
+		params.Burst = int(math.Max(1, math.Ceil(params.Rate)))
+	}
+
+	sink = strings.ToUpper(sink)
+	throttles.Lock()
+	defer throttles.Unlock()
+
+	if params.Window == 0 && params.Rate == 0 {
+		delete(throttles.bySink, sink)
+		return nil
+	}
+	throttles.bySink[sink] = &throttle{
+		params:  params,
+		windows: map[string]*dedupWindow{},
+		tokens:  float64(params.Burst),
+		last:    time.Now(),
+	}
+	return nil
+}
+
+// deliverThrottled delivers an entry to a sink unless the throttle of the sink suppresses it,
+// and records the outcome in the sink statistics. Summaries of suppressed entries are
+// delivered with the same function once their window closes.
+//
+// Parameters:
+//   - sink: The name of the sink.
+//   - e: The entry to deliver.
+//   - deliver: Delivers an entry to the sink.
+//
+// Returns:
+//   - The error of the delivery, or nil if the entry was suppressed.
+func deliverThrottled(sink string, e Entry, deliver func(Entry) error) error {
+	throttles.RLock()
+	t := throttles.bySink[strings.ToUpper(sink)]
+	throttles.RUnlock()
+
+	if t != nil && !t.allow(sink, e, deliver) {
+		recordSuppressed(sink)
+		return nil
+	}
+
+	err := deliver(e)
+	recordDelivery(sink, err)
+	return err
+}
+
+// allow reports whether an entry may be delivered. The first entry of a fingerprint opens a
+// deduplication window, which delivers the summary of its suppressed entries when it closes.
+func (t *throttle) allow(sink string, e Entry, deliver func(Entry) error) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.params.Window > 0 && e.Fingerprint != "" {
+		w, ok := t.windows[e.Fingerprint]
+		if !ok {
+			w = &dedupWindow{}
+			t.windows[e.Fingerprint] = w
+			time.AfterFunc(t.params.Window, func() { t.closeWindow(sink, e.Fingerprint, w, deliver) })
+		}
+
+		w.count++
+		if w.count > t.params.Limit {
+			w.suppressed++
+			w.sample = e
+			return false
+		}
+	}
+
+	if t.params.Rate > 0 {
+		now := time.Now()
+		t.tokens = math.Min(float64(t.params.Burst), t.tokens+now.Sub(t.last).Seconds()*t.params.Rate)
+		t.last = now
+		if t.tokens < 1 {
+			return false
+		}
+		t.tokens--
+	}
+
+	return true
+}
+
+// closeWindow ends a deduplication window and delivers the summary of its suppressed entries.
+// The summary is the last suppressed entry with a message reporting how many were suppressed.
+func (t *throttle) closeWindow(sink, fingerprint string, w *dedupWindow, deliver func(Entry) error) {
+	t.mu.Lock()
+	delete(t.windows, fingerprint)
+	suppressed, summary := w.suppressed, w.sample
+	t.mu.Unlock()
+
+	if suppressed == 0 {
+		return
+	}
+
+	now := time.Now()
+	summary.ID = newID(now)
+	summary.Time = now
+	summary.Message = fmt.Sprintf("suppressed %d similar errors in the last %s", suppressed, t.params.Window)
+	// The context of the sampled entry, such as that of a finished request, is usually done
+	// by the time the window closes, and would keep the summary from being delivered.
+	summary.ctx = nil
+
+	fields := make(map[string]any, len(summary.Fields)+1)
+	for key, value := range summary.Fields {
+		fields[key] = value
+	}
+	fields["suppressed"] = suppressed
+	summary.Fields = fields
+
+	err := deliver(summary)
+	recordDelivery(sink, err)
+	if sink == BotSinkName {
+		reportBotError(err)
+	}
+}
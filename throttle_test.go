@@ -0,0 +1,132 @@
+package errs
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// collectSink records the entries delivered to a sink.
+type collectSink struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+func (c *collectSink) deliver(e Entry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = append(c.entries, e)
+	return nil
+}
+
+func (c *collectSink) delivered() []Entry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]Entry(nil), c.entries...)
+}
+
+func setThrottle(t *testing.T, sink string, params ThrottleParams) {
+	if err := SetThrottle(sink, params); err != nil {
+		t.Fatal("Expected no error, got", err)
+	}
+	t.Cleanup(func() { _ = SetThrottle(sink, ThrottleParams{}) })
+}
+
+func TestSetThrottle_Invalid(t *testing.T) {
+	if err := SetThrottle("TEST", ThrottleParams{Rate: -1}); err == nil {
+		t.Fatal("Expected an error for a negative rate")
+	}
+}
+
+func TestThrottle_Dedup(t *testing.T) {
+	resetStats(t)
+	setThrottle(t, "TEST_DEDUP", ThrottleParams{Window: 50 * time.Millisecond, Limit: 2})
+	sink := &collectSink{}
+
+	// The entries are logged with the context of a request that has finished.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i := 0; i < 5; i++ {
+		deliverThrottled("TEST_DEDUP", Entry{Level: slog.LevelError, Fingerprint: "a", Message: "failed", ctx: ctx}, sink.deliver)
+	}
+	deliverThrottled("TEST_DEDUP", Entry{Level: slog.LevelError, Fingerprint: "b"}, sink.deliver)
+
+	if got := len(sink.delivered()); got != 3 {
+		t.Fatalf("Expected 2 entries of a and 1 of b to be delivered, got %d", got)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for len(sink.delivered()) < 4 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	entries := sink.delivered()
+	if len(entries) != 4 {
+		t.Fatalf("Expected a summary once the window closes, got %d entries", len(entries))
+	}
+	summary := entries[3]
+	if summary.Fingerprint != "a" || summary.Fields["suppressed"] != 3 || !strings.HasPrefix(summary.Message, "suppressed 3 similar errors") {
+		t.Fatalf("Unexpected summary %+v", summary)
+	}
+	if err := summary.context().Err(); err != nil {
+		t.Fatal("Expected the summary not to keep the context of the sampled entry, got", err)
+	}
+
+	for _, s := range GetStatus().Sinks {
+		if s.Name == "TEST_DEDUP" && s.Suppressed != 3 {
+			t.Fatalf("Expected 3 suppressed entries in the status, got %d", s.Suppressed)
+		}
+	}
+
+	// A new window starts after the previous one closed.
+	deliverThrottled("TEST_DEDUP", Entry{Level: slog.LevelError, Fingerprint: "a"}, sink.deliver)
+	if got := len(sink.delivered()); got != 5 {
+		t.Fatalf("Expected the entry to be delivered in a new window, got %d entries", got)
+	}
+}
+
+func TestThrottle_Rate(t *testing.T) {
+	setThrottle(t, "TEST_RATE", ThrottleParams{Rate: 10, Burst: 3})
+	sink := &collectSink{}
+
+	for i := 0; i < 10; i++ {
+		deliverThrottled("TEST_RATE", Entry{Level: slog.LevelError}, sink.deliver)
+	}
+	if got := len(sink.delivered()); got != 3 {
+		t.Fatalf("Expected the burst to be delivered, got %d entries", got)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	deliverThrottled("TEST_RATE", Entry{Level: slog.LevelError}, sink.deliver)
+	if got := len(sink.delivered()); got != 4 {
+		t.Fatalf("Expected the bucket to refill, got %d entries", got)
+	}
+}
+
+func TestSetThrottle_CaseInsensitive(t *testing.T) {
+	setThrottle(t, "test_case", ThrottleParams{Rate: 1, Burst: 1})
+	sink := &collectSink{}
+
+	for i := 0; i < 3; i++ {
+		deliverThrottled("TEST_CASE", Entry{Level: slog.LevelError}, sink.deliver)
+	}
+	if got := len(sink.delivered()); got != 1 {
+		t.Fatalf("Expected the throttle to apply to the sink in any case, got %d entries", got)
+	}
+}
+
+func TestThrottle_Unthrottled(t *testing.T) {
+	sink := &collectSink{}
+	for i := 0; i < 5; i++ {
+		deliverThrottled("TEST_NONE", Entry{Level: slog.LevelError, Fingerprint: "a"}, sink.deliver)
+	}
+
+	if got := len(sink.delivered()); got != 5 {
+		t.Fatalf("Expected every entry to be delivered without a throttle, got %d", got)
+	}
+}
@@ -0,0 +1,172 @@
+package errs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	botV5 "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// verifyTimeout bounds the self-test run by NewBot when BroadcastBotParams.Verify is set.
+const verifyTimeout = 30 * time.Second
+
+// VerifyReport is the result of a bot self-test, with one report per configured chat.
+type VerifyReport struct {
+	Chats []ChatReport
+}
+
+// ChatReport describes whether the bot can reach and post to a chat.
+type ChatReport struct {
+	ChatID    int64
+	Reachable bool   // The bot can see the chat (getChat succeeded).
+	Title     string // Title of a group or channel, or the name of a private chat.
+	Type      string // Chat type: private, group, supergroup or channel.
+	CanPost   bool   // The bot is a member of the chat and allowed to send messages.
+	Error     string // Why the chat is unreachable or the bot cannot post.
+}
+
+// Err returns an error describing every chat the bot cannot post to, or nil if it can post to all of them.
+func (r VerifyReport) Err() error {
+	var errs error
+	for _, c := range r.Chats {
+		if !c.CanPost {
+			errs = Join(" && ", errs, NewF("chat %d: %s", c.ChatID, c.Error))
+		}
+	}
+	return errs
+}
+
+// Verify checks every configured chat with getChat and getChatMember and reports
+// which chats are reachable, their titles, and whether the bot can post to them.
+// The returned error is the report's Err, or the failure to identify the bot itself.
+func (bb *BroadcastBot) Verify(ctx context.Context) (VerifyReport, error) {
+	self, err := bb.user(ctx)
+	if err != nil {
+		return VerifyReport{}, err
+	}
+
+	var report VerifyReport
+	for _, chatID := range bb.chatIDsToVerify() {
+		report.Chats = append(report.Chats, bb.verifyChat(ctx, self.ID, chatID))
+	}
+	return report, report.Err()
+}
+
+// user returns the bot user, requesting it with getMe if the bot does not know it yet,
+// as a lazy bot does until then.
+func (bb *BroadcastBot) user(ctx context.Context) (*botV5.User, error) {
+	if self := bb.self.Load(); self != nil {
+		return self, nil
+	}
+
+	result, err := bb.request(ctx, "getMe", nil)
+	if err != nil {
+		return nil, Wrap(err, "failed to get the bot user")
+	}
+	var self botV5.User
+	if err := json.Unmarshal(result, &self); err != nil {
+		return nil, Wrap(err, "failed to decode the bot user")
+	}
+	bb.self.Store(&self)
+	return &self, nil
+}
+
+// verifyChat checks whether the bot with the user ID selfID can post to a single chat.
+func (bb *BroadcastBot) verifyChat(ctx context.Context, selfID, chatID int64) ChatReport {
+	report := ChatReport{ChatID: chatID}
+	id := strconv.FormatInt(chatID, 10)
+
+	result, err := bb.request(ctx, "getChat", botV5.Params{"chat_id": id})
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+
+	var chat botV5.Chat
+	if err := json.Unmarshal(result, &chat); err != nil {
+		report.Error = err.Error()
+		return report
+	}
+
+	report.Reachable = true
+	report.Type = chat.Type
+	report.Title = chat.Title
+	if chat.IsPrivate() {
+		report.Title = strings.TrimSpace(chat.FirstName + " " + chat.LastName)
+		report.CanPost = true
+		return report
+	}
+
+	params := botV5.Params{"chat_id": id, "user_id": strconv.FormatInt(selfID, 10)}
+	result, err = bb.request(ctx, "getChatMember", params)
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+
+	var member botV5.ChatMember
+	if err := json.Unmarshal(result, &member); err != nil {
+		report.Error = err.Error()
+		return report
+	}
+
+	switch {
+	case member.Status == "left" || member.Status == "kicked":
+		report.Error = "the bot is not a member of the chat"
+	case chat.IsChannel() && member.Status != "creator" && !member.CanPostMessages:
+		report.Error = "the bot is not allowed to post to the channel"
+	case member.Status == "restricted" && !member.CanSendMessages:
+		report.Error = "the bot is not allowed to send messages"
+	default:
+		report.CanPost = true
+	}
+	return report
+}
+
+// SendTestMessage sends a plain text message naming the service and the host to every
+// configured chat and forum topic, so that deployments can confirm alerting works.
+func (bb *BroadcastBot) SendTestMessage(ctx context.Context) error {
+	host := GetMetadata().instance()
+	text := fmt.Sprintf(
+		"Alerting test\nService Name: %s\nHost: %s\nT: %s",
+		bb.service,
+		host,
+		time.Now().Format(time.RFC3339),
+	)
+
+	var errs error
+	for _, chat := range bb.chatsToVerify() {
+		params := chat.params()
+		params["text"] = text
+		if _, err := bb.request(ctx, "sendMessage", params); err != nil {
+			errs = Join(" && ", errs, WrapF(err, "failed to send test message to chat %d", chat.ChatID))
+		}
+	}
+	return errs
+}
+
+// chatsToVerify returns every chat and forum topic the bot may send alerts to, without duplicates.
+func (bb *BroadcastBot) chatsToVerify() []ChatTarget {
+	routing := bb.routes()
+	chats := appendChats(bb.allChats(), routing.defaultRoute...)
+	for _, r := range routing.routes {
+		chats = appendChats(chats, r.Chats...)
+	}
+	return chats
+}
+
+// chatIDsToVerify returns the IDs of the chats the bot may send alerts to, without duplicates.
+func (bb *BroadcastBot) chatIDsToVerify() []int64 {
+	var ids []int64
+	for _, chat := range bb.chatsToVerify() {
+		if !slices.Contains(ids, chat.ChatID) {
+			ids = append(ids, chat.ChatID)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,132 @@
+package errs
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBroadcastBot_Verify(t *testing.T) {
+	f := newFakeBotAPI(t)
+	f.fail = func(req fakeRequest) string {
+		if req.Method == "getChat" && req.Params.Get("chat_id") == "3" {
+			return "Bad Request: chat not found"
+		}
+		return ""
+	}
+	f.respond = func(req fakeRequest) any {
+		switch req.Method {
+		case "getChat":
+			id := req.Params.Get("chat_id")
+			if id == "1" {
+				return map[string]any{"id": 1, "type": "private", "first_name": "Alice"}
+			}
+			return map[string]any{"id": -100, "type": "supergroup", "title": "Alerts " + id}
+		case "getChatMember":
+			if req.Params.Get("chat_id") == "2" {
+				return map[string]any{"status": "left", "user": map[string]any{"id": 123}}
+			}
+			return map[string]any{"status": "member", "user": map[string]any{"id": 123}}
+		}
+		return nil
+	}
+
+	b := newTestBot(t, f, BroadcastBotParams{
+		ChatIDs: []int64{1, 2},
+		Routes:  []Route{{Chats: []ChatTarget{{ChatID: 3}, {ChatID: 4, ThreadID: 9}}}},
+	})
+
+	report, err := b.Verify(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "chat 2") || !strings.Contains(err.Error(), "chat 3") {
+		t.Fatalf("Expected chats 2 and 3 to fail, got %v", err)
+	}
+
+	want := []ChatReport{
+		{ChatID: 1, Reachable: true, Title: "Alice", Type: "private", CanPost: true},
+		{ChatID: 2, Reachable: true, Title: "Alerts 2", Type: "supergroup", Error: "the bot is not a member of the chat"},
+		{ChatID: 3, Error: "Bad Request: chat not found"},
+		{ChatID: 4, Reachable: true, Title: "Alerts 4", Type: "supergroup", CanPost: true},
+	}
+	if len(report.Chats) != len(want) {
+		t.Fatalf("Expected %d chat reports, got %v", len(want), report.Chats)
+	}
+	for i := range want {
+		if report.Chats[i] != want[i] {
+			t.Errorf("Chat report %d = %+v, want %+v", i, report.Chats[i], want[i])
+		}
+	}
+
+	if member := f.calls("getChatMember"); len(member) == 0 || member[0].Params.Get("user_id") != "123" {
+		t.Fatal("Expected the membership of the bot itself to be checked")
+	}
+}
+
+func TestBroadcastBot_VerifyConcurrent(t *testing.T) {
+	f := newFakeBotAPI(t)
+	setupFakeBot(t, f, BroadcastBotParams{ChatIDs: []int64{1}, Lazy: true})
+	b := Default().Bot()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if _, err := b.Verify(context.Background()); err != nil {
+				t.Error("Expected no error, got", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			Default().getLogger(Entry{Time: time.Now(), Level: slog.LevelError, Message: "failed"})
+		}()
+	}
+	wg.Wait()
+
+	for _, member := range f.calls("getChatMember") {
+		if member.Params.Get("user_id") != "123" {
+			t.Fatalf("Expected the membership of the bot itself to be checked, got %v", member.Params)
+		}
+	}
+	if self := b.self.Load(); self == nil || self.ID != 123 {
+		t.Fatalf("Expected the bot user to be known, got %v", self)
+	}
+}
+
+func TestBroadcastBot_SendTestMessage(t *testing.T) {
+	f := newFakeBotAPI(t)
+	b := newTestBot(t, f, BroadcastBotParams{
+		ServiceName: "billing",
+		ChatIDs:     []int64{1},
+		Routes:      []Route{{Chats: []ChatTarget{{ChatID: 1, ThreadID: 9}}}},
+	})
+
+	if err := b.SendTestMessage(context.Background()); err != nil {
+		t.Fatal("Expected no error, got", err)
+	}
+
+	sent := f.calls("sendMessage")
+	if len(sent) != 2 || sent[1].Params.Get("message_thread_id") != "9" {
+		t.Fatalf("Expected a test message to every chat and topic, got %v", sent)
+	}
+	if !strings.Contains(sent[0].Params.Get("text"), "Service Name: billing\nHost: ") {
+		t.Fatalf("Unexpected test message %q", sent[0].Params.Get("text"))
+	}
+}
+
+func TestNewBot_Verify(t *testing.T) {
+	f := newFakeBotAPI(t)
+	f.fail = func(req fakeRequest) string {
+		if req.Method == "getChat" {
+			return "Bad Request: chat not found"
+		}
+		return ""
+	}
+
+	_, err := NewBot(BroadcastBotParams{Token: fakeBotToken, APIEndpoint: f.URL, ChatIDs: []int64{1}, Verify: true})
+	if err == nil {
+		t.Fatal("Expected the self-test to fail")
+	}
+}
@@ -1,11 +1,9 @@
 package errs
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"log/slog"
-	"strings"
+	"sync"
 	"time"
 )
 
@@ -62,12 +60,17 @@ func WrapF(err error, format string, args ...any) error {
 //     If the provided error is nil, the function returns nil.
 func wrap(err error, args ...any) error {
 	// Join the provided arguments into a single message string.
+	separator := currentSettings().separator
 	message := JoinMsg(separator, args...)
 
 	return &errorString{
 		// Combine the new message with the original error's message.
 		message: JoinMsg(separator, message, Unwrap(err)),
 		origErr: err.Error(),
+		code:    Code(err),
+		stack:   stackOrCallers(err, 2),
+		attrs:   errorAttrs(err),
+		causes:  []error{err},
 	}
 }
 
@@ -118,7 +121,9 @@ func UnwrapE(err error) error {
 		return err
 	}
 
-	return New(e.unwrap())
+	// Keep the stack and the attributes of the wrapped error, so that UnwrapE keeps its fingerprint frames.
+	message := e.unwrap()
+	return &errorString{message: message, origErr: message, stack: e.stack, attrs: e.attrs, causes: e.causes}
 }
 
 // Log asynchronously logs an error with additional context messages and a request object.
@@ -137,84 +142,152 @@ func UnwrapE(err error) error {
 // Returns:
 //   - This function does not return any value.
 func Log(err error, req any, msgs ...any) {
-	if err == nil {
-		return
-	}
+	defaultLogger.Log(err, req, msgs...)
+}
+
+// LogID logs an error like Log and returns the unique ID of the entry.
+// The ID is logged as the "error_id" field and shown in bot messages, so that it can be
+// shown to end users as a reference and looked up in the logs and chats.
+//
+// Parameters:
+//   - err: The error to log. If this is nil, the function returns without doing anything.
+//   - req: The request object associated with the error. This can be of any type.
+//   - msgs: Variadic arguments representing additional messages to include in the log entry.
+//
+// Returns:
+//   - The ID of the entry, a 26 character string that sorts by time.
+//     If the provided error is nil, it returns an empty string.
+func LogID(err error, req any, msgs ...any) string {
+	return defaultLogger.LogID(err, req, msgs...)
+}
 
-	// Asynchronously handle the error logging to prevent blocking.
-	go logError(err, req, msgs...)
+// Log asynchronously logs an error like the package-level Log, using the loggers,
+// the service name and the bot of l.
+func (l *Logger) Log(err error, req any, msgs ...any) {
+	l.LogID(err, req, msgs...)
 }
 
-// logError asynchronously logs an error with additional context messages and a request object.
-// If the error is nil, it does nothing.
+// LogID asynchronously logs an error like the package-level LogID, using the loggers,
+// the service name and the bot of l.
+func (l *Logger) LogID(err error, req any, msgs ...any) string {
+	return l.LogCtx(context.Background(), err, req, msgs...)
+}
+
+// LogCtx asynchronously logs an error like the package-level LogCtx, using the loggers,
+// the service name and the bot of l.
+func (l *Logger) LogCtx(ctx context.Context, err error, req any, msgs ...any) string {
+	return l.LogWith(err, Ctx(ctx), Req(req), Msg(msgs...))
+}
+
+// logError logs an error with the options of a LogWith call.
 //
-// The function logs the error using the appropriate logger based on the current logging level and type.
-// It constructs a combined message by joining all provided messages with the separator separator.
+// The function builds the entry, joining all messages of the options into a unified message.
 // The original error message is logged as the "Error Path" field in the log entry.
 // The request object is logged as the "request" field in the log entry.
 //
 // Parameters:
-//   - err: The error to log. If this is nil, the function returns without doing anything.
-//   - req: The request object associated with the error. This can be of any type.
-//   - msgs: Variadic arguments representing additional messages to include in the log entry.
+//   - t: The time the error was logged.
+//   - id: The ID of the entry.
+//   - err: The error to log. It must not be nil.
+//   - o: The options of the call. Its context carries the attributes of the entry.
 //
 // Returns:
 //   - This function does not return any value.
-func logError(err error, req any, msgs ...any) {
-	// Join all provided messages to create a unified error message.
-	message := JoinMsg(separator, msgs...)
-	errorPath := slog.String("Error Path", Unwrap(err))
+func (l *Logger) logError(t time.Time, id string, err error, o logOptions) {
+	// Build the entry, joining all provided messages into a unified error message.
+	// Its level and whether it is alerted come from the policy, see SetPolicy,
+	// unless the options of the call override them. Hooks may then change or drop it.
+	level, alert := Classify(err)
+	if o.level != nil {
+		level = *o.level
+	}
+	e := Entry{
+		ID:          id,
+		Time:        t,
+		Level:       level,
+		Service:     l.serviceName(),
+		Message:     JoinMsg(currentSettings().separator, o.msgs...),
+		ErrorPath:   Unwrap(err),
+		RootCause:   err.Error(),
+		Code:        Code(err),
+		Request:     o.req,
+		Fields:      entryFields(extractedAttrs(o.ctx), errorAttrs(err), contextAttrs(o.ctx), o.fields),
+		Fingerprint: Fingerprint(err),
+		Metadata:    GetMetadata(),
+		ctx:         o.ctx,
+		noAlert:     !alert || o.noNotify,
+		only:        o.only,
+		sync:        o.sync,
+	}
 
-	// Retrieve the logger based on the current logging level and type.
-	getLogger(message, errorPath, slog.Any("request", req))
+	if !beforeLog(o.ctx, err, &e) {
+		return
+	}
+	observe(o.ctx, err, e)
+	l.getLogger(e)
 }
 
-// getLogger retrieves and logs an error message with additional context using the appropriate logger.
-// It iterates through a list of sloggers and asynchronously logs the error message with the provided arguments.
-// If a bot is configured, it also sends the error message to the configured Telegram chats.
+// getLogger logs an entry using all configured loggers.
+// It iterates through a list of sloggers and logs the entry to all of them concurrently.
+// If a bot is configured, it also sends the entry to the Telegram chats it is routed to,
+// unless the policy does not alert it, see SetPolicy. Entries logged with Only are delivered
+// to the named sinks only, and filters may keep entries from sinks, see AddFilter. Entries
+// with a muted fingerprint are counted but not delivered anywhere, and sinks with a throttle
+// may suppress entries, see SetThrottle.
 //
 // Parameters:
-//   - msg: A string representing the error message to be logged.
-//   - args: Variadic arguments representing additional context to be logged alongside the error message.
+//   - e: The entry to be logged.
 //
 // Returns:
-//   - This function does not return any value. It logs the error message asynchronously.
-func getLogger(msg string, args ...any) {
+//   - This function does not return any value. It returns once the entry is delivered to every sink.
+func (l *Logger) getLogger(e Entry) {
+	errorStats.record(e)
+	recentEntries.add(e)
+	if IsMuted(e.Fingerprint) {
+		return
+	}
+
 	// Log to all configured loggers
-	for _, slog := range slogLoggers {
-		go slog.Error(msg, args...)
+	var wg sync.WaitGroup
+	for _, sink := range l.sinks() {
+		if !e.delivers(string(sink.name)) || !accepts(string(sink.name), e) {
+			continue
+		}
+		wg.Add(1)
+		go func(sink logSink) {
+			defer wg.Done()
+			deliverThrottled(string(sink.name), e, func(e Entry) error { return writeEntry(sink.name, e) })
+		}(sink)
 	}
 
-	// Always log to jsonLogger
-	if jsonLogger != nil {
-		jsonLogger.Error(msg, args...)
+	// Send JSON log to Telegram, unless the policy or the caller say the error is not alerted
+	if bot := l.Bot(); bot != nil && !e.noAlert && e.delivers(BotSinkName) && accepts(BotSinkName, e) {
+		reportBotError(deliverThrottled(BotSinkName, e, bot.notify))
 	}
 
-	// Send JSON log to Telegram
-	if bot != nil && jsonBuf != nil {
-		jsonMsg := jsonBuf.String()
-		jsonBuf.Reset()
-
-		if bot.trimSpace {
-			jsonMsg = strings.TrimSpace(jsonMsg)
-		} else {
-			var prettyJSON bytes.Buffer
-			if err := json.Indent(&prettyJSON, []byte(jsonMsg), "", "  "); err == nil {
-				jsonMsg = prettyJSON.String()
-			}
-		}
+	wg.Wait()
+}
 
-		logMsg := fmt.Sprintf(
-			"Service Name: %s\nT: %s\n```json\n%s\n```",
-			sTitle,
-			time.Now().Format(time.RFC3339Nano),
-			jsonMsg,
-		)
-
-		if err := bot.sendMessage(logMsg); err != nil {
-			for _, slog := range slogLoggers {
-				go slog.Error("Failed to send message to Telegram", "error", err.Error())
-			}
-		}
+// reportBotError logs a failure to deliver a message to Telegram using all configured loggers.
+// It does nothing if err is nil.
+func reportBotError(err error) {
+	if err == nil {
+		return
+	}
+
+	go logInternal("Failed to send message to Telegram", "error", err.Error())
+}
+
+// writeEntry writes an entry to the logger of a log type, holding the log file open until
+// the entry is written. The logger is taken from the current configuration, since entries
+// such as throttle summaries may be written long after they were logged.
+func writeEntry(t LogType, e Entry) error {
+	s := acquireSettings()
+	defer s.release()
+
+	logger, ok := s.loggers[t]
+	if !ok {
+		return nil
 	}
+	return e.log(logger)
 }